@@ -3,6 +3,7 @@ package main
 import (
 	"bot/config"
 	"bot/internal/bot"
+	"bot/internal/calendar"
 	"bot/internal/handler"
 	"bot/internal/service"
 	"bot/internal/storage"
@@ -22,6 +23,11 @@ func main() {
 		log.Fatalf("config error: %s", err)
 	}
 
+	cal, err := calendar.New(cfg.Calendar)
+	if err != nil {
+		log.Fatalf("calendar error: %s", err)
+	}
+
 	store, err := storage.New(cfg.StorageConfig)
 	if err != nil {
 		log.Fatalf("storage error: %s", err)
@@ -43,12 +49,12 @@ func main() {
 		log.Fatalf("zap error: %s", err)
 	}
 
-	b, err := bot.New(cfg.Key, schedule, logger, store)
+	b, err := bot.New(cfg.Key, schedule, cfg.Bells, cal, logger, store)
 	if err != nil {
 		log.Fatalf("bot error: %s", err)
 	}
 
-	core := service.NewCore(schedule, store)
+	core := service.NewCore(schedule, cal, store)
 
 	h, start, stop, err := handler.New(cfg, core)
 	if err != nil {
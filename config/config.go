@@ -1,6 +1,8 @@
 package config
 
 import (
+	"bot/internal/bells"
+	"bot/internal/calendar"
 	"bot/internal/storage"
 	"encoding/json"
 	"errors"
@@ -16,10 +18,12 @@ var (
 
 // Config contains all the settings for configuring the application.
 type Config struct {
-	Files         []string       `json:"files"`
-	MaxPairPerDay int            `json:"max_pair_per_day"`
-	Key           string         `json:"key"`
-	StorageConfig storage.Config `json:"storage"`
+	Files         []string        `json:"files"`
+	MaxPairPerDay int             `json:"max_pair_per_day"`
+	Key           string          `json:"key"`
+	StorageConfig storage.Config  `json:"storage"`
+	Bells         bells.Config    `json:"bells"`
+	Calendar      calendar.Config `json:"calendar"`
 }
 
 // New initializing the config for the application.
@@ -37,5 +41,9 @@ func New() (Config, error) {
 		return c, fmt.Errorf("read json: %w", err)
 	}
 
+	if err := c.Bells.Validate(); err != nil {
+		return c, fmt.Errorf("validate: %w", err)
+	}
+
 	return c, nil
 }
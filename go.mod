@@ -5,13 +5,18 @@ go 1.19
 require (
 	github.com/xuri/excelize/v2 v2.8.0
 	go.uber.org/zap v1.24.0
+	gopkg.in/telebot.v3 v3.1.3
 	gopkg.in/telegram-bot-api.v4 v4.6.4
+	gorm.io/driver/postgres v1.5.2
 	gorm.io/driver/sqlite v1.5.3
 	gorm.io/gorm v1.25.4
 )
 
 require (
 	github.com/go-telegram-bot-api/telegram-bot-api v4.6.4+incompatible // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/jackc/pgx/v5 v5.3.1 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/mattn/go-sqlite3 v1.14.17 // indirect
@@ -27,5 +32,4 @@ require (
 	golang.org/x/crypto v0.12.0 // indirect
 	golang.org/x/net v0.14.0 // indirect
 	golang.org/x/text v0.12.0 // indirect
-	gopkg.in/telebot.v3 v3.1.3 // indirect
 )
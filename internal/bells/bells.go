@@ -0,0 +1,213 @@
+// Package bells describes the times of the pairs, they may differ by campus,
+// by weekday and on shortened days.
+package bells
+
+import (
+	"bot/internal/constant"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bell is the start and the end of a pair in minutes since midnight.
+type Bell struct {
+	Start int
+	End   int
+}
+
+// Schedule is the bells of a day, the first one is the pair №1.
+type Schedule []Bell
+
+// Standard is the schedule used when the config has none.
+var Standard = Schedule{
+	{Start: 7*60 + 25, End: 8*60 + 55},
+	{Start: 9*60 + 0, End: 10*60 + 30},
+	{Start: 10*60 + 40, End: 12*60 + 10},
+	{Start: 12*60 + 30, End: 14*60 + 0},
+	{Start: 14*60 + 20, End: 15*60 + 50},
+	{Start: 16*60 + 0, End: 17*60 + 30},
+}
+
+// Set is the schedules of a campus: the default one, the ones for weekdays
+// ("monday", ...) and the ones for dates, e.g. shortened days before holidays.
+type Set struct {
+	Default  Schedule            `json:"default"`
+	Weekdays map[string]Schedule `json:"weekdays"`
+	Dates    map[string]Schedule `json:"dates"`
+}
+
+// Config is the bells of all campuses, a campus missing in it or a day
+// missing in its set falls back to the common set and then to Standard.
+type Config struct {
+	Set
+	Campuses map[string]Set `json:"campuses"`
+}
+
+// For returns the schedule of the campus on the date.
+func (c Config) For(campus string, date time.Time) Schedule {
+	if set, ok := c.Campuses[campus]; ok {
+		if s, ok := set.lookup(date); ok {
+			return s
+		}
+	}
+
+	if s, ok := c.Set.lookup(date); ok {
+		return s
+	}
+
+	return Standard
+}
+
+// MaxPairs returns the number of pairs of the longest schedule.
+func (c Config) MaxPairs() int {
+	max := len(Standard)
+	c.each(func(_ string, s Schedule) {
+		if len(s) > max {
+			max = len(s)
+		}
+	})
+
+	return max
+}
+
+// Validate checks that the pairs don't overlap and the days are known.
+func (c Config) Validate() error {
+	var errs []string
+
+	c.each(func(name string, s Schedule) {
+		if err := s.validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	})
+
+	for campus, set := range c.Campuses {
+		errs = append(errs, set.validateKeys(campus)...)
+	}
+	errs = append(errs, c.Set.validateKeys("")...)
+
+	if len(errs) != 0 {
+		return fmt.Errorf("bells: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// each calls f for every schedule of the config with its name.
+func (c Config) each(f func(name string, s Schedule)) {
+	c.Set.each("", f)
+	for campus, set := range c.Campuses {
+		set.each(campus, f)
+	}
+}
+
+func (s Set) lookup(date time.Time) (Schedule, bool) {
+	if sch, ok := s.Dates[date.Format(constant.DateLayout)]; ok {
+		return sch, true
+	}
+
+	if sch, ok := s.Weekdays[strings.ToLower(date.Weekday().String())]; ok {
+		return sch, true
+	}
+
+	return s.Default, len(s.Default) != 0
+}
+
+func (s Set) each(prefix string, f func(name string, s Schedule)) {
+	if len(s.Default) != 0 {
+		f(prefix+"/default", s.Default)
+	}
+	for day, sch := range s.Weekdays {
+		f(prefix+"/"+day, sch)
+	}
+	for date, sch := range s.Dates {
+		f(prefix+"/"+date, sch)
+	}
+}
+
+func (s Set) validateKeys(prefix string) []string {
+	var errs []string
+
+	for day := range s.Weekdays {
+		if _, ok := weekdays[day]; !ok {
+			errs = append(errs, fmt.Sprintf("%s: unknown weekday %q", prefix, day))
+		}
+	}
+
+	for date := range s.Dates {
+		if _, err := time.Parse(constant.DateLayout, date); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: bad date %q", prefix, date))
+		}
+	}
+
+	return errs
+}
+
+var weekdays = map[string]struct{}{
+	"monday": {}, "tuesday": {}, "wednesday": {}, "thursday": {}, "friday": {}, "saturday": {}, "sunday": {},
+}
+
+func (s Schedule) validate() error {
+	prevEnd := -1
+	for i, b := range s {
+		if b.Start >= b.End {
+			return fmt.Errorf("pair %d ends before it starts", i+1)
+		}
+		if b.Start < prevEnd {
+			return fmt.Errorf("pair %d starts before pair %d ends", i+1, i)
+		}
+		prevEnd = b.End
+	}
+
+	return nil
+}
+
+// ErrBadBell is returned for a bell not in the "H:MM-H:MM" form.
+var ErrBadBell = errors.New("bell must look like 9:00-10:30")
+
+// UnmarshalJSON parses the bell written as "9:00-10:30".
+func (b *Bell) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return fmt.Errorf("%q: %w", s, ErrBadBell)
+	}
+
+	var err error
+	if b.Start, err = parseMinute(start); err != nil {
+		return fmt.Errorf("%q: %w", s, err)
+	}
+	if b.End, err = parseMinute(end); err != nil {
+		return fmt.Errorf("%q: %w", s, err)
+	}
+
+	return nil
+}
+
+// MarshalJSON writes the bell as "9:00-10:30".
+func (b Bell) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+func (b Bell) String() string {
+	return FormatMinute(b.Start) + "-" + FormatMinute(b.End)
+}
+
+func parseMinute(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, ErrBadBell
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// FormatMinute formats minutes since midnight as H:MM.
+func FormatMinute(m int) string {
+	return fmt.Sprintf("%d:%02d", m/60, m%60)
+}
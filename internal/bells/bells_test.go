@@ -0,0 +1,85 @@
+package bells
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const testConfig = `{
+	"default": ["8:00-9:30", "9:40-11:10"],
+	"campuses": {
+		"Kamen": {
+			"weekdays": {"saturday": ["9:00-10:00"]},
+			"dates": {"2024-12-28": ["8:00-9:00", "9:10-10:10"]}
+		}
+	}
+}`
+
+func TestConfig_For(t *testing.T) {
+	var c Config
+	if err := json.Unmarshal([]byte(testConfig), &c); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	monday := time.Date(2024, time.December, 23, 0, 0, 0, 0, time.UTC)
+	saturday := time.Date(2024, time.December, 21, 0, 0, 0, 0, time.UTC)
+	shortened := time.Date(2024, time.December, 28, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		campus string
+		date   time.Time
+		want   Schedule
+	}{
+		{"common default", "Baskov", saturday, Schedule{{480, 570}, {580, 670}}},
+		{"campus falls back", "Kamen", monday, Schedule{{480, 570}, {580, 670}}},
+		{"campus weekday", "Kamen", saturday, Schedule{{540, 600}}},
+		{"campus date", "Kamen", shortened, Schedule{{480, 540}, {550, 610}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.For(tt.campus, tt.date)
+			if len(got) != len(tt.want) {
+				t.Fatalf("For() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("For() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if got := (Config{}).For("Kamen", monday); len(got) != len(Standard) {
+		t.Errorf("empty For() = %v, want Standard", got)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	bad := []string{
+		`{"default": ["9:00-8:00"]}`,
+		`{"default": ["8:00-9:30", "9:00-10:00"]}`,
+		`{"weekdays": {"funday": ["8:00-9:00"]}}`,
+		`{"campuses": {"Kamen": {"dates": {"28.12": ["8:00-9:00"]}}}}`,
+	}
+
+	for _, s := range bad {
+		var c Config
+		if err := json.Unmarshal([]byte(s), &c); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", s, err)
+		}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%s) error = nil", s)
+		}
+	}
+
+	var c Config
+	if err := json.Unmarshal([]byte(`{"default": ["8:00"]}`), &c); err == nil {
+		t.Errorf("Unmarshal() error = nil")
+	}
+}
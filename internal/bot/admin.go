@@ -0,0 +1,577 @@
+package bot
+
+import (
+	"bot/internal/broadcast"
+	"bot/internal/constant"
+	"bot/internal/entity/table"
+	"bot/internal/service"
+	"errors"
+	"fmt"
+	api "gopkg.in/telegram-bot-api.v4"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// version is the version of the bot shown by /status, it is set at build time
+// with -ldflags "-X bot/internal/bot.version=...".
+var version = "dev"
+
+// maxStatsGroups is the number of the largest groups shown by /stats.
+const maxStatsGroups = 15
+
+const adminHelp = `Команды администратора:
+/stats — статистика
+/status — версия, время обновления расписания и предупреждения
+/reload — перечитать расписание
+/deliveries [дата] [id или @ник] — отправленные уведомления
+/broadcast all текст — рассылка всем
+/broadcast campus <корпус> текст — рассылка корпусу
+/broadcast group 04 74-20 текст — рассылка группе
+/user <id или @ник> — информация о пользователе
+/user <id или @ник> set group|subgroup|subscribed|pair|headman <значение>
+/user <id или @ник> delete
+/setadmin <id или @ник> [off]
+/headman <id или @ник> [off]
+/unsub_all_from_pairs — отписать всех от пар`
+
+// adminUser returns the user who sent the command if the user is an admin.
+// Every admin command is logged, attempts of other users are logged too.
+func (b *Bot) adminUser(msg *api.Message) (table.User, bool) {
+	user, err := b.storage.GetUserByID(msg.From.ID)
+	if err != nil {
+		if !errors.Is(err, constant.ErrUserNotFound) {
+			b.logger.Warn(fmt.Sprintf("get user error: %v", err.Error()))
+		}
+		return user, false
+	}
+
+	if !user.Admin {
+		b.logger.Warn(fmt.Sprintf("user %d is not an admin: /%s %s", user.ID, msg.Command(), msg.CommandArguments()))
+		return user, false
+	}
+
+	b.logger.Info(fmt.Sprintf("admin %d: /%s %s", user.ID, msg.Command(), msg.CommandArguments()))
+
+	return user, true
+}
+
+// handleAdminCommand handles the admin commands, it reports whether the command
+// is one of them.
+func (b *Bot) handleAdminCommand(msg *api.Message) bool {
+	handlers := map[string]func(table.User, string){
+		"admin":                b.showAdminHelp,
+		"stats":                b.handleStats,
+		"status":               b.handleStatus,
+		"reload":               b.handleReload,
+		"deliveries":           b.handleDeliveries,
+		"broadcast":            b.handleBroadcast,
+		"user":                 b.handleUserCommand,
+		"setadmin":             b.handleSetAdmin,
+		"headman":              b.handleHeadmanCommand,
+		"unsub_all_from_pairs": b.handleUnsubAllFromPairs,
+	}
+
+	handler, ok := handlers[msg.Command()]
+	if !ok {
+		return false
+	}
+
+	if admin, ok := b.adminUser(msg); ok {
+		handler(admin, strings.TrimSpace(msg.CommandArguments()))
+	}
+
+	return true
+}
+
+func (b *Bot) showAdminHelp(admin table.User, _ string) {
+	b.send(newMsgForUser(b.adminHelpText(), admin.ChatID, nil))
+}
+
+// adminHelpText returns the admin commands along with the campuses, they are
+// the names of the schedule files.
+func (b *Bot) adminHelpText() string {
+	campuses := b.schedule.Campuses()
+	if len(campuses) == 0 {
+		return adminHelp
+	}
+
+	return adminHelp + "\n\nКорпуса: " + strings.Join(campuses, ", ")
+}
+
+func (b *Bot) handleStats(admin table.User, _ string) {
+	users, err := b.storage.GetUsers()
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("handleStats get users error: %v", err.Error()))
+		return
+	}
+
+	var subscribed, pair, headmen, blocked int
+	byGroup := make(map[string]int)
+	byCampus := make(map[string]int)
+
+	for _, u := range users {
+		if u.Subscribed {
+			subscribed++
+		}
+		if u.SubscribedPair {
+			pair++
+		}
+		if u.Headman {
+			headmen++
+		}
+		if u.Blocked {
+			blocked++
+		}
+		if u.Group != "" {
+			byGroup[u.Group]++
+			byCampus[b.schedule.Campus(u.Group)]++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Пользователей: %d\n", len(users)))
+	sb.WriteString(fmt.Sprintf("Подписаны на расписание: %d\n", subscribed))
+	sb.WriteString(fmt.Sprintf("Подписаны на пары: %d\n", pair))
+	sb.WriteString(fmt.Sprintf("Старост: %d\n", headmen))
+	sb.WriteString(fmt.Sprintf("Заблокировали бота: %d\n", blocked))
+	sb.WriteString(fmt.Sprintf("Групп: %d\n", len(byGroup)))
+
+	sb.WriteString("\nПо корпусам:\n")
+	for _, c := range sortedByCount(byCampus) {
+		name := c
+		if name == "" {
+			name = "неизвестно"
+		}
+		sb.WriteString(fmt.Sprintf("%s — %d\n", name, byCampus[c]))
+	}
+
+	sb.WriteString("\nПо группам:\n")
+	for i, g := range sortedByCount(byGroup) {
+		if i == maxStatsGroups {
+			sb.WriteString("…\n")
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%s — %d\n", g, byGroup[g]))
+	}
+
+	b.send(newMsgForUser(sb.String(), admin.ChatID, nil))
+}
+
+// sortedByCount returns the keys of the counts from the largest count.
+func sortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+func (b *Bot) handleStatus(admin table.User, _ string) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Версия: %s\n", version))
+	sb.WriteString(fmt.Sprintf("Расписание обновлено: %s\n", b.schedule.UpdatedAt().In(mskLoc).Format("02.01.2006 15:04")))
+	sb.WriteString(fmt.Sprintf("Групп в расписании: %d\n", len(b.schedule.GetDayGroupNames())))
+	sb.WriteString(fmt.Sprintf("Корпуса: %s\n", strings.Join(b.schedule.Campuses(), ", ")))
+
+	warnings := b.schedule.Warnings()
+	if len(warnings) == 0 {
+		sb.WriteString("\nПредупреждений нет.")
+	} else {
+		sb.WriteString(fmt.Sprintf("\nПредупреждения (%d):\n", len(warnings)))
+		for i, w := range warnings {
+			if i == maxListed {
+				sb.WriteString("…\n")
+				break
+			}
+			sb.WriteString("• " + w + "\n")
+		}
+	}
+
+	b.send(newMsgForUser(sb.String(), admin.ChatID, nil))
+}
+
+// handleReload re-parses the schedule files and notifies the users and the
+// chats of the groups whose schedule changed.
+func (b *Bot) handleReload(admin table.User, _ string) {
+	changed, err := b.schedule.Reload()
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("handleReload error: %v", err.Error()))
+		b.send(newMsgForUser("Ошибка обновления расписания: "+err.Error(), admin.ChatID, nil))
+		return
+	}
+
+	b.send(newMsgForUser(
+		fmt.Sprintf("Расписание обновлено. Изменилось у групп: %d, предупреждений: %d.", len(changed), len(b.schedule.Warnings())),
+		admin.ChatID, nil,
+	))
+
+	b.notifyChanged(changed)
+}
+
+// handleDeliveries shows the scheduled notifications sent on the date, today
+// by default: the numbers by kind, or the list for one user.
+func (b *Bot) handleDeliveries(admin table.User, args string) {
+	now := time.Now().In(mskLoc)
+	date := now
+
+	var user table.User
+	words := strings.Fields(args)
+
+	if len(words) > 0 {
+		if u, err := b.userByRef(words[len(words)-1]); err == nil {
+			user = u
+			words = words[:len(words)-1]
+		}
+	}
+
+	if len(words) > 0 {
+		var ok bool
+		if date, ok = service.ParseDate(strings.Join(words, " "), now); !ok {
+			b.send(newMsgForUser("Не понял дату или пользователя.", admin.ChatID, nil))
+			return
+		}
+	}
+
+	ds, err := b.storage.GetDeliveries(date.Format(constant.DateLayout), user.ID)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("handleDeliveries error: %v", err.Error()))
+		b.send(newMsgForUser("Ошибка получения уведомлений.", admin.ChatID, nil))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Уведомления на %s", date.Format("02.01.2006")))
+	if user.ID != 0 {
+		sb.WriteString(fmt.Sprintf(" для %s (%d)", user.Name, user.ID))
+	}
+	sb.WriteString(fmt.Sprintf(": %d\n\n", len(ds)))
+
+	if user.ID == 0 {
+		counts := make(map[string]int)
+		for _, d := range ds {
+			counts[d.Kind]++
+		}
+		for _, kind := range sortedByCount(counts) {
+			sb.WriteString(fmt.Sprintf("%s: %d\n", kind, counts[kind]))
+		}
+	} else {
+		for i, d := range ds {
+			if i == maxListed {
+				sb.WriteString("…\n")
+				break
+			}
+			sb.WriteString(deliveryText(d) + "\n")
+		}
+	}
+
+	b.send(newMsgForUser(sb.String(), admin.ChatID, nil))
+}
+
+// deliveryText describes the delivery for /deliveries.
+func deliveryText(d table.Delivery) string {
+	text := d.SentAt.In(mskLoc).Format("15:04") + " " + d.Kind
+	if d.Pair != 0 {
+		text += fmt.Sprintf(", пара №%d", d.Pair)
+	}
+	if d.Ref != 0 {
+		text += fmt.Sprintf(", #%d", d.Ref)
+	}
+
+	return text
+}
+
+// notifyChanged tells the subscribers and the bound chats of the groups that
+// their schedule changed.
+func (b *Bot) notifyChanged(groups []string) {
+	if len(groups) == 0 {
+		return
+	}
+
+	const text = "🔄 Расписание твоей группы изменилось, проверь его!"
+	now := time.Now().In(mskLoc)
+
+	for _, g := range groups {
+		for _, u := range b.registry.dailyUsers(g) {
+			if !isMuted(u, now) {
+				b.send(newMsgForUser(text, u.ChatID, &toScheduleKeyboard))
+			}
+		}
+	}
+
+	chats, err := b.storage.GetSubscribedChats()
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("notifyChanged get chats error: %v", err.Error()))
+		return
+	}
+
+	changed := make(map[string]struct{}, len(groups))
+	for _, g := range groups {
+		changed[g] = struct{}{}
+	}
+
+	for _, c := range chats {
+		if _, ok := changed[c.Group]; ok {
+			b.send(api.NewMessage(c.ID, "🔄 Расписание группы "+c.Group+" изменилось."))
+		}
+	}
+}
+
+// handleBroadcast sends the message to all users, to the users of a campus or
+// of a group.
+func (b *Bot) handleBroadcast(admin table.User, args string) {
+	recipients, text, ok := b.broadcastRecipients(args)
+	if !ok || text == "" {
+		b.send(newMsgForUser(b.adminHelpText(), admin.ChatID, nil))
+		return
+	}
+
+	b.startBroadcast(admin, text, recipients)
+}
+
+// startBroadcast queues the text to the recipients, the author is told the
+// progress by the broadcast engine.
+func (b *Bot) startBroadcast(author table.User, text string, recipients []table.User) {
+	msgs := make([]broadcast.Message, 0, len(recipients))
+	for _, u := range recipients {
+		msgs = append(msgs, broadcast.Message{
+			UserID: u.ID,
+			ChatID: u.ChatID,
+			Msg:    newMsgForUser(text, u.ChatID, &toScheduleKeyboard),
+		})
+	}
+
+	record, err := b.broadcast.Broadcast(author.ID, author.ChatID, text, msgs)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("broadcast error: %v", err.Error()))
+		b.send(newMsgForUser("Ошибка рассылки: "+err.Error(), author.ChatID, nil))
+		return
+	}
+
+	b.send(newMsgForUser(fmt.Sprintf("Рассылка #%d запущена, получателей: %d.", record.ID, record.Total), author.ChatID, nil))
+}
+
+// broadcastRecipients parses the target of the broadcast and returns the users
+// to send the rest of the arguments to.
+func (b *Bot) broadcastRecipients(args string) ([]table.User, string, bool) {
+	words := strings.Fields(args)
+	if len(words) < 2 {
+		return nil, "", false
+	}
+
+	switch words[0] {
+	case "all":
+		users, err := b.storage.GetUsers()
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("broadcast get users error: %v", err.Error()))
+			return nil, "", false
+		}
+		return activeUsers(users), strings.Join(words[1:], " "), true
+	case "campus":
+		users, err := b.storage.GetUsers()
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("broadcast get users error: %v", err.Error()))
+			return nil, "", false
+		}
+
+		// campus names contain spaces, the longest known one is taken
+		for i := len(words); i > 1; i-- {
+			campus, ok := b.findCampus(strings.Join(words[1:i], " "))
+			if !ok {
+				continue
+			}
+
+			var campusUsers []table.User
+			for _, u := range users {
+				if !u.Blocked && u.Group != "" && b.schedule.Campus(u.Group) == campus {
+					campusUsers = append(campusUsers, u)
+				}
+			}
+			return campusUsers, strings.Join(words[i:], " "), true
+		}
+	case "group":
+		// group names contain spaces, the longest known one is taken
+		for i := len(words); i > 1; i-- {
+			group := strings.Join(words[1:i], " ")
+			if !b.schedule.VerifyGroup(group) {
+				continue
+			}
+
+			users, err := b.storage.GetUsersByGroup(group)
+			if err != nil {
+				b.logger.Warn(fmt.Sprintf("broadcast get users error: %v", err.Error()))
+				return nil, "", false
+			}
+			return users, strings.Join(words[i:], " "), true
+		}
+	}
+
+	return nil, "", false
+}
+
+// activeUsers returns the users who didn't block the bot.
+func activeUsers(users []table.User) []table.User {
+	active := users[:0]
+	for _, u := range users {
+		if !u.Blocked {
+			active = append(active, u)
+		}
+	}
+
+	return active
+}
+
+// findCampus returns the campus with the name ignoring the case.
+func (b *Bot) findCampus(name string) (string, bool) {
+	for _, c := range b.schedule.Campuses() {
+		if strings.EqualFold(c, name) {
+			return c, true
+		}
+	}
+
+	return "", false
+}
+
+// handleUserCommand shows the user or changes one of the user's fields.
+func (b *Bot) handleUserCommand(admin table.User, args string) {
+	words := strings.Fields(args)
+	if len(words) == 0 {
+		b.send(newMsgForUser(b.adminHelpText(), admin.ChatID, nil))
+		return
+	}
+
+	user, err := b.userByRef(words[0])
+	if err != nil {
+		if errors.Is(err, constant.ErrUserNotFound) {
+			b.send(newMsgForUser("Пользователь не найден.", admin.ChatID, nil))
+			return
+		}
+		b.logger.Warn(fmt.Sprintf("handleUserCommand get user error: %v", err.Error()))
+		return
+	}
+
+	if len(words) == 2 && words[1] == "delete" {
+		if err := b.storage.DeleteUser(user.ID); err != nil {
+			b.logger.Warn(fmt.Sprintf("handleUserCommand delete error: %v", err.Error()))
+			return
+		}
+		b.registry.remove(user.ID)
+
+		b.send(newMsgForUser(fmt.Sprintf("Пользователь %d удален.", user.ID), admin.ChatID, nil))
+		return
+	}
+
+	if len(words) >= 4 && words[1] == "set" {
+		if err := b.setUserField(&user, words[2], strings.Join(words[3:], " ")); err != nil {
+			b.send(newMsgForUser("Ошибка: "+err.Error(), admin.ChatID, nil))
+			return
+		}
+
+		if err := b.saveUser(user); err != nil {
+			b.logger.Warn(fmt.Sprintf("handleUserCommand save error: %v", err.Error()))
+			return
+		}
+	}
+
+	b.send(newMsgForUser(userInfo(user), admin.ChatID, nil))
+}
+
+// setUserField changes the field of the user by its name in /user set.
+func (b *Bot) setUserField(user *table.User, field string, value string) error {
+	parseBool := func() (bool, error) {
+		switch value {
+		case "on", "true", "1", "да":
+			return true, nil
+		case "off", "false", "0", "нет":
+			return false, nil
+		}
+		return false, fmt.Errorf("ожидается on или off")
+	}
+
+	var err error
+	switch field {
+	case "group":
+		if !b.schedule.VerifyGroup(value) {
+			return fmt.Errorf("неверная группа")
+		}
+		if user.Group != value {
+			user.Headman = false
+		}
+		user.Group = value
+	case "subgroup":
+		var sg int
+		if sg, err = strconv.Atoi(value); err != nil || sg < 0 || sg > 2 {
+			return fmt.Errorf("подгруппа 0, 1 или 2")
+		}
+		user.SubGroup = sg
+	case "subscribed":
+		user.Subscribed, err = parseBool()
+	case "pair":
+		user.SubscribedPair, err = parseBool()
+	case "headman":
+		user.Headman, err = parseBool()
+	default:
+		return fmt.Errorf("неизвестное поле %s", field)
+	}
+
+	return err
+}
+
+func userInfo(u table.User) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("ID: %d\nChatID: %d\nИмя: %s\n", u.ID, u.ChatID, u.Name))
+	if u.Nickname != "" {
+		sb.WriteString(fmt.Sprintf("Ник: @%s\n", u.Nickname))
+	}
+	sb.WriteString(fmt.Sprintf("Группа: %s, подгруппа: %d\n", u.Group, u.SubGroup))
+	sb.WriteString(fmt.Sprintf("Расписание: %v, %s\n", u.Subscribed, dailyTimeText(u)))
+	sb.WriteString(fmt.Sprintf("На завтра вечером: %v, неделя вперед: %v\n", u.SubscribedEvening, u.SubscribedWeekly))
+	sb.WriteString(fmt.Sprintf("Часовой пояс: %s\n", timeZoneText(u)))
+	sb.WriteString(fmt.Sprintf("Пары: %v, за %d мин, %s\n", u.SubscribedPair, u.RemindBefore, remindModeText(u.RemindMode)))
+	sb.WriteString(fmt.Sprintf("Админ: %v, староста: %v, заблокировал бота: %v\n", u.Admin, u.Headman, u.Blocked))
+	if status := muteStatus(u, time.Now().In(mskLoc)); status != "" {
+		sb.WriteString(status + "\n")
+	}
+
+	return sb.String()
+}
+
+func (b *Bot) handleSetAdmin(admin table.User, args string) {
+	words := strings.Fields(args)
+	if len(words) == 0 || len(words) > 2 || (len(words) == 2 && words[1] != "off") {
+		b.send(newMsgForUser("Пример: /setadmin @nickname или /setadmin 123456 off", admin.ChatID, nil))
+		return
+	}
+
+	user, err := b.userByRef(words[0])
+	if err != nil {
+		if errors.Is(err, constant.ErrUserNotFound) {
+			b.send(newMsgForUser("Пользователь не найден.", admin.ChatID, nil))
+			return
+		}
+		b.logger.Warn(fmt.Sprintf("handleSetAdmin get user error: %v", err.Error()))
+		return
+	}
+
+	if user.ID == admin.ID && len(words) == 2 {
+		b.send(newMsgForUser("Нельзя снять права администратора с себя.", admin.ChatID, nil))
+		return
+	}
+
+	user.Admin = len(words) == 1
+	if err := b.saveUser(user); err != nil {
+		b.logger.Warn(fmt.Sprintf("handleSetAdmin save error: %v", err.Error()))
+		return
+	}
+
+	b.send(newMsgForUser(userInfo(user), admin.ChatID, nil))
+}
@@ -1,8 +1,12 @@
 package bot
 
 import (
+	"bot/internal/bells"
+	"bot/internal/broadcast"
+	"bot/internal/calendar"
 	"bot/internal/constant"
 	"bot/internal/entity/table"
+	"bot/internal/scheduler"
 	"bot/internal/service"
 	"bot/internal/storage"
 	"context"
@@ -11,25 +15,29 @@ import (
 	"go.uber.org/zap"
 	api "gopkg.in/telegram-bot-api.v4"
 	"log"
-	"sync"
+	"strings"
 	"time"
 )
 
 // Bot represents a bot.
 type Bot struct {
-	token       string
-	subscribers map[int]struct{}
-	mu          sync.RWMutex
-
-	logger   *zap.Logger
-	schedule *service.ScheduleService
-	storage  *storage.Storage
+	token    string
+	registry *registry
+	ledger   *ledger
+	clock    scheduler.Clock
+
+	logger    *zap.Logger
+	schedule  *service.ScheduleService
+	bells     bells.Config
+	calendar  *calendar.Calendar
+	storage   storage.Repository
+	broadcast *broadcast.Engine
 
 	*api.BotAPI
 }
 
 // New creates a new bot.
-func New(token string, schedule *service.ScheduleService, logger *zap.Logger, storage *storage.Storage) (*Bot, error) {
+func New(token string, schedule *service.ScheduleService, bells bells.Config, calendar *calendar.Calendar, logger *zap.Logger, storage storage.Repository) (*Bot, error) {
 
 	b, err := api.NewBotAPI(token)
 	if err != nil {
@@ -39,10 +47,17 @@ func New(token string, schedule *service.ScheduleService, logger *zap.Logger, st
 	bot := &Bot{
 		token:    token,
 		schedule: schedule,
+		bells:    bells,
+		calendar: calendar,
 		BotAPI:   b,
 		logger:   logger,
 		storage:  storage,
+		registry: newRegistry(),
+		clock:    scheduler.Real(),
 	}
+	bot.ledger = newLedger(storage, logger)
+	bot.broadcast = broadcast.New(b, storage, logger)
+	bot.broadcast.OnUndeliverable(bot.deactivate)
 
 	return bot, nil
 }
@@ -59,25 +74,35 @@ func (b *Bot) Start(ctx context.Context) error {
 		return fmt.Errorf("get updates chan: %w", err)
 	}
 
-	err = b.formSubscribers()
-	if err != nil && !errors.Is(err, constant.ErrNoSubscribers) {
-		return fmt.Errorf("form subscribers: %w", err)
+	users, err := b.storage.GetUsers()
+	if err != nil {
+		return fmt.Errorf("get users: %w", err)
 	}
+	b.registry.load(users)
 
 	mskLoc, err = time.LoadLocation("Europe/Moscow")
 	if err != nil {
 		log.Fatalf("sendToSubscribers: load location error: %s", err)
 	}
 
-	go b.sendDailyToSubscribers(ctx)
-	go b.sendNextPairToSubscribers(ctx)
+	b.broadcast.Start(ctx)
+
+	s := scheduler.New(b.clock, mskLoc, b.logger)
+	b.addJobs(s)
+	go s.Run(ctx)
 
 	for update := range updates {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
 
+		if update.InlineQuery != nil {
+			b.handleInlineQuery(update.InlineQuery)
+			continue
+		}
+
 		if update.CallbackQuery != nil {
+			b.reactivate(update.CallbackQuery.From.ID)
 			b.handleCallbackQuery(update.CallbackQuery)
 			continue
 		}
@@ -86,6 +111,13 @@ func (b *Bot) Start(ctx context.Context) error {
 			continue
 		}
 
+		if !update.Message.Chat.IsPrivate() {
+			b.handleChatMessage(update.Message)
+			continue
+		}
+
+		b.reactivate(update.Message.From.ID)
+
 		if update.Message.IsCommand() {
 			b.handleCommand(update.Message)
 			continue
@@ -97,189 +129,464 @@ func (b *Bot) Start(ctx context.Context) error {
 	return nil
 }
 
+// deactivate marks the user who can't receive messages anymore as blocked and
+// stops notifying the user, a group chat the bot was removed from is unbound.
+func (b *Bot) deactivate(chatID int64, reason broadcast.Reason) {
+	if chatID < 0 {
+		if err := b.storage.DeleteChat(chatID); err != nil {
+			b.logger.Warn(fmt.Sprintf("deactivate error: DeleteChat error: %v", err.Error()))
+		}
+		return
+	}
+
+	user, err := b.storage.GetUserByChatID(chatID)
+	if err != nil {
+		if !errors.Is(err, constant.ErrUserNotFound) {
+			b.logger.Warn(fmt.Sprintf("deactivate error: GetUserByChatID error: %v", err.Error()))
+		}
+		return
+	}
+
+	if user.Blocked {
+		return
+	}
+
+	user.Blocked = true
+	if err := b.saveUser(user); err != nil {
+		b.logger.Warn(fmt.Sprintf("deactivate error: SaveUser error: %v", err.Error()))
+		return
+	}
+
+	b.logger.Info(fmt.Sprintf("user %d deactivated: %s", user.ID, reason))
+}
+
+// reactivate resumes notifying the user who blocked the bot and wrote to it
+// again. The registry is checked first, so the storage is written only for the
+// blocked users.
+func (b *Bot) reactivate(userID int) {
+	if !b.registry.isBlocked(userID) {
+		return
+	}
+
+	ok, err := b.storage.Reactivate(userID)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("reactivate error: %v", err.Error()))
+		return
+	}
+
+	if !ok {
+		return
+	}
+
+	user, err := b.storage.GetUserByID(userID)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("reactivate error: GetUserByID error: %v", err.Error()))
+		return
+	}
+
+	b.registry.set(user)
+
+	b.logger.Info(fmt.Sprintf("user %d reactivated", user.ID))
+}
+
+// saveUser saves the user and keeps the registry of the notified users in
+// sync, every change of a user must go through it.
+func (b *Bot) saveUser(user table.User) error {
+	if err := b.storage.SaveUser(user); err != nil {
+		return err
+	}
+
+	b.registry.set(user)
+
+	return nil
+}
+
 // Stop stops the bot.
 func (b *Bot) Stop() {
 	b.StopReceivingUpdates()
 }
 
-func (b *Bot) sendDailyToSubscribers(ctx context.Context) {
-	lastDay := time.Weekday(-1)
+// reloadHour is the hour the schedule files are read again every night.
+const reloadHour = 5
+
+// addJobs adds the senders, the reload of the schedule and the pruning of the
+// ledger to the scheduler.
+func (b *Bot) addJobs(s *scheduler.Scheduler) {
+	s.Add("daily", scheduler.Every(time.Minute), func(_ context.Context, at time.Time) {
+		b.sendDaily(at)
+	})
+	s.Add("events", scheduler.Every(time.Minute), func(_ context.Context, at time.Time) {
+		b.remindEvents(at)
+	})
+	s.Add("pairs", scheduler.OnDays(scheduler.Every(time.Minute), b.calendar.Open), func(_ context.Context, at time.Time) {
+		b.sendNextPair(at)
+	})
+	s.Add("reload", scheduler.Daily(reloadHour, 0), func(context.Context, time.Time) {
+		b.reloadSchedule()
+	})
+	s.Add("ledger", scheduler.Daily(reloadHour, 0), func(_ context.Context, at time.Time) {
+		b.ledger.prune(at)
+	})
+}
 
-	for ctx.Err() == nil {
-		now := time.Now().In(mskLoc)
-		hour := now.Hour()
-		day := now.Weekday()
+// reloadSchedule reads the schedule files again and tells the groups whose
+// schedule changed.
+func (b *Bot) reloadSchedule() {
+	changed, err := b.schedule.Reload()
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("reloadSchedule error: %v", err.Error()))
+		return
+	}
+
+	b.notifyChanged(changed)
+}
 
-		isWeekend := day == time.Saturday || day == time.Sunday
-		if (hour != 8 || day == lastDay) || isWeekend {
+// eveningHour is the hour the schedule for tomorrow is sent to the users who
+// want it the evening before.
+const eveningHour = 20
+
+// sendDaily sends the daily schedule and the homework digest, it is run every
+// minute. The ledger keeps each of them from being sent twice, the ones missed
+// during a restart are sent within catchUpGrace.
+func (b *Bot) sendDaily(now time.Time) {
+	for _, n := range b.notifiedUsers() {
+		user := n.user
+
+		b.sendHomeworkDigest(user, now)
+		b.sendEveningPreview(user, now)
+		b.sendWeeklyDigest(user, now)
+
+		date, at := dailyDate(user, now)
+		if !due(at, now) {
 			continue
 		}
 
-		lastDay = day
+		// the muted users lose the schedule as if it was sent
+		muted := isMuted(user, now)
 
-		b.mu.RLock()
-		for id := range b.subscribers {
-			user, err := b.storage.GetUserByID(id)
-			if err != nil {
-				b.logger.Warn(fmt.Sprintf("sendToSubscribers error: GetUserByID error: %v", err.Error()))
-				continue
+		title := "Расписание на %s %s:"
+		if user.DailyEvening {
+			title = "Расписание на завтра, %s %s:"
+		}
+
+		if user.Subscribed && b.calendar.Day(user.Group, date).Study &&
+			b.ledger.claim(delivery(user, table.DeliveryDaily, 0, date, 0), now) && !muted {
+			if user.DailyEvening {
+				b.send(b.dateMessage(user, primaryView(user), date, title))
+			} else {
+				b.send(b.dateMessage(user, primaryView(user), date, "Твое ближайшее расписание на %s %s:"))
 			}
+		}
 
-			b.send(b.handleSchedule("-1", 0, user))
+		for _, f := range n.follows {
+			if f.Daily && b.calendar.Day(f.Group, date).Study &&
+				b.ledger.claim(delivery(user, table.DeliveryDaily, f.ID, date, 0), now) && !muted {
+				b.send(b.dateMessage(user, followView(f), date, title))
+			}
 		}
-		b.mu.RUnlock()
+	}
+
+	b.sendDailyToChats(now)
+}
+
+// sendHomeworkDigest sends the subscriber the homework due tomorrow once in
+// the evening.
+func (b *Bot) sendHomeworkDigest(user table.User, now time.Time) {
+	local := now.In(userLoc(user))
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	tomorrow := collegeDate(today.AddDate(0, 0, 1))
+
+	if !user.Subscribed || !due(today.Add(eveningHour*time.Hour), now) || !b.calendar.Day(user.Group, tomorrow).Study {
+		return
+	}
+
+	if !b.ledger.claim(delivery(user, table.DeliveryDigest, 0, tomorrow, 0), now) || isMuted(user, now) {
+		return
+	}
+
+	if text := b.homeworkDigest(user.Group, tomorrow); text != "" {
+		b.send(newMsgForUser(text, user.ChatID, &toScheduleKeyboard))
 	}
 }
 
-func (b *Bot) formSubscribers() error {
-	b.subscribers = make(map[int]struct{})
+// sendEveningPreview sends the subscriber tomorrow's schedule with the notices,
+// the homework and the events known so far, once in the evening. The users who
+// get the daily schedule in the evening already have it.
+func (b *Bot) sendEveningPreview(user table.User, now time.Time) {
+	if !user.SubscribedEvening || (user.Subscribed && user.DailyEvening) {
+		return
+	}
 
-	subs, err := b.storage.GetSubscribers()
-	if err != nil {
-		return err
+	local := now.In(userLoc(user))
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	tomorrow := collegeDate(today.AddDate(0, 0, 1))
+
+	if !due(today.Add(eveningHour*time.Hour), now) || !b.calendar.Day(user.Group, tomorrow).Study {
+		return
 	}
 
-	for _, user := range subs {
-		b.subscribers[user.ID] = struct{}{}
+	if !b.ledger.claim(delivery(user, table.DeliveryEvening, 0, tomorrow, 0), now) || isMuted(user, now) {
+		return
 	}
 
-	return nil
+	b.send(b.dateMessage(user, primaryView(user), tomorrow, "Завтра, %s %s:"))
+}
+
+// sendWeeklyDigest sends the subscriber the schedule of the coming week on
+// Sunday evening.
+func (b *Bot) sendWeeklyDigest(user table.User, now time.Time) {
+	if !user.SubscribedWeekly {
+		return
+	}
+
+	local := now.In(userLoc(user))
+	if local.Weekday() != time.Sunday {
+		return
+	}
+
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	monday := collegeDate(today.AddDate(0, 0, 1))
+
+	if !due(today.Add(eveningHour*time.Hour), now) {
+		return
+	}
+
+	if !b.ledger.claim(delivery(user, table.DeliveryWeekly, 0, monday, 0), now) || isMuted(user, now) {
+		return
+	}
+
+	b.send(newMsgForUser(b.weekDigest(user, monday), user.ChatID, &toScheduleKeyboard))
 }
 
-const maxPairs = 6
+// weekDigest renders the week starting on monday for the user's group: the
+// study days with their pairs and notices, and the days off.
+func (b *Bot) weekDigest(user table.User, monday time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Расписание на неделю с %s:\n", monday.Format("02.01")))
 
-func (b *Bot) sendNextPairToSubscribers(ctx context.Context) {
-	offset := 0
+	for i := 0; i < 7; i++ {
+		date := monday.AddDate(0, 0, i)
 
-	for ctx.Err() == nil {
-		now := time.Now().In(mskLoc)
-		day := now.Weekday()
-		if day == time.Saturday || day == time.Sunday {
-			sleepToTheEndOfDay(now)
+		// plain weekends are not worth a line
+		if cd := b.calendar.Day(user.Group, date); !cd.Study && cd.Reason == "" {
 			continue
 		}
 
-		time.Sleep(5 * time.Second)
+		text, err := b.dateText(user.Group, user.SubGroup, date, false)
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("weekDigest error: %v", err.Error()))
+			continue
+		}
 
-		offset = sleepUntilPair(now, offset+1)
+		sb.WriteString("\n" + text + "\n")
+	}
 
-		b.mu.RLock()
-		for id := range b.subscribers {
-			if ctx.Err() != nil {
-				return
-			}
+	return sb.String()
+}
 
-			user, err := b.storage.GetUserByID(id)
-			if err != nil {
-				b.logger.Warn(fmt.Sprintf("sendToSubscribers error: GetUserByID error: %v", err.Error()))
-				continue
-			}
+// dailyDate returns the date whose schedule is sent to the user on the day of
+// now and the time it is sent at: today at the user's hour or tomorrow in the
+// evening, in the user's time zone. The date is in the college's zone, the
+// days off are checked by the caller for every group.
+func dailyDate(user table.User, now time.Time) (date time.Time, at time.Time) {
+	local := now.In(userLoc(user))
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
 
-			if now.Before(user.SilenceUntil.In(mskLoc)) || !user.SubscribedPair {
-				continue
-			}
+	if user.DailyEvening {
+		return collegeDate(today.AddDate(0, 0, 1)), today.Add(eveningHour * time.Hour)
+	}
 
-			msg, err := b.handleNextPair(user, offset)
-			if err != nil {
-				if errors.Is(err, ErrNoPair) {
-					continue
-				}
-				b.logger.Warn(fmt.Sprintf("sendNextPairToSubscribers error: handleNextPair error: %v", err.Error()))
-				continue
-			}
+	hour := user.DailyHour
+	if hour == 0 {
+		hour = table.DefaultDailyHour
+	}
+
+	return collegeDate(today), today.Add(time.Duration(hour) * time.Hour)
+}
+
+// bellsFor returns the pair times of the group's campus on the date.
+// Reminders are sent the user's lead time before the start of a pair.
+func (b *Bot) bellsFor(group string, date time.Time) bells.Schedule {
+	return b.bells.For(b.schedule.Campus(group), date)
+}
 
-			b.send(msg)
+// sendNextPair reminds the subscribers about their next pairs, it is run
+// every minute of the study days.
+func (b *Bot) sendNextPair(now time.Time) {
+	// the days of the groups are shared by all their users
+	days := make(map[string]service.WorkDay)
+	dayOf := func(group string) (service.WorkDay, bool) {
+		if day, ok := days[group]; ok {
+			return day, true
 		}
 
-		offset++
-		b.mu.RUnlock()
+		cd := b.calendar.Day(group, now)
+		if !cd.Study {
+			return nil, false
+		}
 
-		if offset >= maxPairs {
-			offset = 0
-			sleepToTheEndOfDay(now)
+		day, err := b.schedule.GetDayByGroup(group, weekdayToInt(cd.Weekday))
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("sendNextPair error: GetDayByGroup error: %v", err.Error()))
+			return nil, false
 		}
+
+		days[group] = day
+		return day, true
 	}
-}
 
-func (b *Bot) silence(user table.User) {
-	now := time.Now()
-	toTheEndOfTheDay := time.Hour*24 - (time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute + time.Duration(now.Second())*time.Second)
-	user.SilenceUntil = now.In(mskLoc).Add(toTheEndOfTheDay)
+	for group, users := range b.registry.pairByGroup() {
+		day, ok := dayOf(group)
+		if !ok {
+			continue
+		}
 
-	log.Println("silence until:", user.SilenceUntil)
+		for _, user := range users {
+			if !isMuted(user, now) {
+				b.remindPair(user, primaryView(user), day, now)
+			}
+		}
+	}
 
-	err := b.storage.SaveUser(user)
+	follows, err := b.storage.GetSubscribedFollows()
 	if err != nil {
-		b.logger.Warn(fmt.Sprintf("silence error: SaveUser error: %v", err.Error()))
+		b.logger.Warn(fmt.Sprintf("sendNextPair error: GetSubscribedFollows error: %v", err.Error()))
+		return
 	}
 
-	b.send(newMsgForUser("Вы отписались от рассылки расписания до конца дня!", user.ChatID, &toScheduleKeyboard))
+	for _, f := range follows {
+		if !f.Pair {
+			continue
+		}
+
+		user, ok := b.followOwner(f.UserID)
+		if !ok || isMuted(user, now) {
+			continue
+		}
+
+		if day, ok := dayOf(f.Group); ok {
+			b.remindPair(user, followView(f), day, now)
+		}
+	}
 }
 
-func sleepUntilPair(now time.Time, pair int) int {
-	switch pair {
-	case 1:
-		firstPair := time.Date(now.Year(), now.Month(), now.Day(), 8, 55, 0, 0, mskLoc)
-		if !now.After(firstPair) {
-			sleepUntilTime(firstPair)
-			return pair
+// remindPair sends the reminder about the pair of the viewed group if it is
+// time to.
+func (b *Bot) remindPair(user table.User, v view, day service.WorkDay, now time.Time) {
+	offset, ok := pairToRemind(user, v.subGroup, day, b.bellsFor(v.group, now), now.Hour()*60+now.Minute())
+	if !ok || !b.ledger.claim(delivery(user, table.DeliveryPair, v.followID, now, offset+1), now) {
+		return
+	}
+
+	msg, err := b.handleNextPair(user, v, offset)
+	if err != nil {
+		if errors.Is(err, ErrNoPair) {
+			return
 		}
-		pair++
-		fallthrough
-	case 2:
-		secondPair := time.Date(now.Year(), now.Month(), now.Day(), 10, 30, 0, 0, mskLoc)
-		if !now.After(secondPair) {
-			sleepUntilTime(secondPair)
-			return pair
+		b.logger.Warn(fmt.Sprintf("remindPair error: handleNextPair error: %v", err.Error()))
+		return
+	}
+
+	b.send(msg)
+}
+
+// notified is a user to notify along with the user's followed groups with
+// notifications on.
+type notified struct {
+	user    table.User
+	follows []table.Follow
+}
+
+// notifiedUsers returns the subscribers and the users with notifications on
+// for their followed groups.
+func (b *Bot) notifiedUsers() []notified {
+	users := b.registry.all()
+
+	list := make([]notified, 0, len(users))
+	byID := make(map[int]int, len(users))
+	for _, u := range users {
+		byID[u.ID] = len(list)
+		list = append(list, notified{user: u})
+	}
+
+	follows, err := b.storage.GetSubscribedFollows()
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("notifiedUsers error: GetSubscribedFollows error: %v", err.Error()))
+	}
+
+	for _, f := range follows {
+		i, ok := byID[f.UserID]
+		if !ok {
+			user, ok := b.followOwner(f.UserID)
+			if !ok {
+				continue
+			}
+
+			i = len(list)
+			byID[f.UserID] = i
+			list = append(list, notified{user: user})
 		}
-		pair++
-		fallthrough
-	case 3:
-		thirdPair := time.Date(now.Year(), now.Month(), now.Day(), 12, 10, 0, 0, mskLoc)
-		if !now.After(thirdPair) {
-			sleepUntilTime(thirdPair)
-			return pair
+
+		list[i].follows = append(list[i].follows, f)
+	}
+
+	return list
+}
+
+// followOwner returns the user who follows a group, false if the user can't be
+// notified.
+func (b *Bot) followOwner(id int) (table.User, bool) {
+	if user, ok := b.registry.user(id); ok {
+		return user, true
+	}
+
+	user, err := b.storage.GetUserByID(id)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("followOwner error: GetUserByID error: %v", err.Error()))
+		return user, false
+	}
+
+	return user, !user.Blocked
+}
+
+// pairToRemind returns the index of the pair of the subgroup the user must be
+// reminded about at the minute of the day, honouring the user's lead time and
+// reminder mode. The reminder is due from the lead time before the pair until
+// its start, so the one missed during a restart is still sent.
+func pairToRemind(user table.User, subGroup int, day service.WorkDay, bs bells.Schedule, minute int) (int, bool) {
+	prevRoom := ""
+	first := true
+
+	for i, pairE := range day {
+		if i >= len(bs) {
+			break
 		}
-		pair++
-		fallthrough
-	case 4:
-		fourthPair := time.Date(now.Year(), now.Month(), now.Day(), 14, 00, 0, 0, mskLoc)
-		if !now.After(fourthPair) {
-			sleepUntilTime(fourthPair)
-			return pair
+
+		p, err := findGroup(pairE, subGroup)
+		if err != nil {
+			continue
 		}
-		pair++
-		fallthrough
-	case 5:
-		fifthPair := time.Date(now.Year(), now.Month(), now.Day(), 15, 50, 0, 0, mskLoc)
-		if !now.After(fifthPair) {
-			sleepUntilTime(fifthPair)
-			return pair
+
+		remind := bs[i].Start-user.RemindBefore <= minute && minute < bs[i].Start
+		switch user.RemindMode {
+		case table.RemindFirst:
+			remind = remind && first
+		case table.RemindRoomChange:
+			remind = remind && (first || p.Room != prevRoom)
 		}
-		pair++
-		fallthrough
-	case 6:
-		sixthPair := time.Date(now.Year(), now.Month(), now.Day(), 17, 30, 0, 0, mskLoc)
-		if !now.After(sixthPair) {
-			sleepUntilTime(sixthPair)
-			return pair
+
+		if remind {
+			return i, true
 		}
-		pair++
-		fallthrough
-	default:
-		log.Println("sleepUntilPair: sleep to the end of the day")
-		sleepToTheEndOfDay(now)
-		time.Sleep(time.Second * 5)
-		return sleepUntilPair(time.Now().In(mskLoc), 1)
+
+		prevRoom = p.Room
+		first = false
 	}
-}
 
-func sleepToTheEndOfDay(now time.Time) {
-	log.Println("sleep to the end of the day")
-	sleepUntilTime(now.Add(time.Hour*24 - time.Duration(now.Hour())*time.Hour - time.Duration(now.Minute())*time.Minute - time.Duration(now.Second())*time.Second))
+	return 0, false
 }
 
-func sleepUntilTime(t time.Time) {
-	log.Println("sleep until:", t)
-	time.Sleep(time.Until(t))
+// silence mutes the user until the end of the day.
+func (b *Bot) silence(user table.User) {
+	b.handleMute(user, muteDay)
 }
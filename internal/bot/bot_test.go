@@ -0,0 +1,242 @@
+package bot
+
+import (
+	"bot/config"
+	"bot/internal/bells"
+	"bot/internal/broadcast"
+	"bot/internal/calendar"
+	"bot/internal/entity/table"
+	"bot/internal/service"
+	"bot/internal/storage"
+	"context"
+	"go.uber.org/zap"
+	api "gopkg.in/telegram-bot-api.v4"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPairToRemind(t *testing.T) {
+	bs := bells.Schedule{
+		{Start: 9 * 60, End: 10*60 + 30},
+		{Start: 10*60 + 40, End: 12*60 + 10},
+		{Start: 12*60 + 30, End: 14 * 60},
+		{Start: 14*60 + 20, End: 15*60 + 50},
+	}
+	day := service.WorkDay{
+		nil,
+		{{Subject: "Математика", Room: "101"}},
+		{{Subject: "Физика", Room: "101", Group: 1}},
+		{{Subject: "Химия", Room: "101", Group: 1}, {Subject: "История", Room: "303", Group: 2}},
+	}
+
+	tests := []struct {
+		name     string
+		mode     int
+		before   int
+		subGroup int
+		at       int
+		want     int
+		wantOk   bool
+	}{
+		{name: "lead time start", mode: table.RemindAll, subGroup: 1, at: 10*60 + 30, want: 1, wantOk: true},
+		{name: "before lead time", mode: table.RemindAll, subGroup: 1, at: 10*60 + 29},
+		{name: "pair started", mode: table.RemindAll, subGroup: 1, at: 10*60 + 40},
+		{name: "no pair", mode: table.RemindAll, subGroup: 1, at: 8*60 + 55},
+		{name: "long lead time", mode: table.RemindAll, before: 30, subGroup: 1, at: 10*60 + 10, want: 1, wantOk: true},
+		{name: "subgroup pair", mode: table.RemindAll, subGroup: 1, at: 12*60 + 20, want: 2, wantOk: true},
+		{name: "other subgroup pair", mode: table.RemindAll, subGroup: 2, at: 12*60 + 20},
+		{name: "first", mode: table.RemindFirst, subGroup: 1, at: 10*60 + 35, want: 1, wantOk: true},
+		{name: "not first", mode: table.RemindFirst, subGroup: 1, at: 12*60 + 20},
+		{name: "first room", mode: table.RemindRoomChange, subGroup: 1, at: 10*60 + 35, want: 1, wantOk: true},
+		{name: "same room", mode: table.RemindRoomChange, subGroup: 1, at: 14*60 + 15},
+		{name: "room changed", mode: table.RemindRoomChange, subGroup: 2, at: 14*60 + 15, want: 3, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := table.User{RemindMode: tt.mode, RemindBefore: 10}
+			if tt.before != 0 {
+				user.RemindBefore = tt.before
+			}
+
+			got, ok := pairToRemind(user, tt.subGroup, day, bs, tt.at)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("pairToRemind() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+// testGroup is a group of the schedule file used by the tests.
+const testGroup = "01 51-21"
+
+// testSender passes the messages sent by the bot to the test.
+type testSender chan api.MessageConfig
+
+func (s testSender) Send(c api.Chattable) (api.Message, error) {
+	s <- c.(api.MessageConfig)
+	return api.Message{}, nil
+}
+
+// newTestBot returns a bot with the schedule of one campus and November 4,
+// 2024 off, and the sender of its messages.
+func newTestBot(t *testing.T) (*Bot, testSender) {
+	t.Helper()
+	loadMsk(t)
+
+	schedule, err := service.NewSchedule(config.Config{Files: []string{"../../Baskov.xlsx"}, MaxPairPerDay: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schedule.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	cal, err := calendar.New(calendar.Config{
+		Holidays: []calendar.Holiday{{Date: "2024-11-04", Title: "День народного единства"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := storage.NewMemory()
+	sender := make(testSender, 10)
+
+	b := &Bot{
+		schedule:  schedule,
+		calendar:  cal,
+		storage:   repo,
+		logger:    zap.NewNop(),
+		registry:  newRegistry(),
+		ledger:    newLedger(repo, zap.NewNop()),
+		broadcast: broadcast.New(sender, repo, zap.NewNop()),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	b.broadcast.Start(ctx)
+
+	return b, sender
+}
+
+// sentTo returns the texts sent to the chat so far. A marker is sent after
+// them, the messages to the same chat are delivered in order.
+func sentTo(t *testing.T, b *Bot, sender testSender, chatID int64) []string {
+	t.Helper()
+
+	const marker = "marker"
+	b.send(api.NewMessage(chatID, marker))
+
+	var texts []string
+	for {
+		select {
+		case msg := <-sender:
+			if msg.ChatID == chatID && msg.Text == marker {
+				return texts
+			}
+			texts = append(texts, msg.Text)
+		case <-time.After(5 * time.Second):
+			t.Fatal("marker is not sent")
+		}
+	}
+}
+
+func TestSendEveningPreview(t *testing.T) {
+	b, sender := newTestBot(t)
+
+	// Tuesday, the pairs are held on Wednesday
+	tuesday := time.Date(2024, time.November, 5, eveningHour, 0, 0, 0, mskLoc)
+	// Sunday, Monday is a holiday
+	sunday := time.Date(2024, time.November, 3, eveningHour, 0, 0, 0, mskLoc)
+
+	tests := []struct {
+		name  string
+		user  table.User
+		now   time.Time
+		wantN int
+	}{
+		{name: "evening", user: table.User{SubscribedEvening: true}, now: tuesday, wantN: 1},
+		{name: "before evening", user: table.User{SubscribedEvening: true}, now: tuesday.Add(-time.Minute)},
+		{name: "not subscribed", user: table.User{}, now: tuesday},
+		{name: "daily in the evening", user: table.User{SubscribedEvening: true, Subscribed: true, DailyEvening: true}, now: tuesday},
+		{name: "no pairs tomorrow", user: table.User{SubscribedEvening: true}, now: sunday},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := tt.user
+			user.ID, user.ChatID, user.Group = i+1, int64(i+1), testGroup
+
+			b.sendEveningPreview(user, tt.now)
+			// the preview is sent once
+			b.sendEveningPreview(user, tt.now)
+
+			texts := sentTo(t, b, sender, user.ChatID)
+			if len(texts) != tt.wantN {
+				t.Fatalf("sent %q, want %d messages", texts, tt.wantN)
+			}
+			if tt.wantN > 0 && !strings.HasPrefix(texts[0], "Завтра, Среда 06.11:") {
+				t.Errorf("preview %q is not for Wednesday", texts[0])
+			}
+		})
+	}
+}
+
+func TestSendWeeklyDigest(t *testing.T) {
+	b, sender := newTestBot(t)
+
+	sunday := time.Date(2024, time.November, 3, eveningHour, 0, 0, 0, mskLoc)
+
+	tests := []struct {
+		name  string
+		user  table.User
+		now   time.Time
+		wantN int
+	}{
+		{name: "sunday evening", user: table.User{SubscribedWeekly: true}, now: sunday, wantN: 1},
+		{name: "saturday evening", user: table.User{SubscribedWeekly: true}, now: sunday.AddDate(0, 0, -1)},
+		{name: "sunday morning", user: table.User{SubscribedWeekly: true}, now: sunday.Add(-12 * time.Hour)},
+		{name: "not subscribed", user: table.User{}, now: sunday},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := tt.user
+			user.ID, user.ChatID, user.Group = i+1, int64(i+1), testGroup
+
+			b.sendWeeklyDigest(user, tt.now)
+			// the digest is sent once
+			b.sendWeeklyDigest(user, tt.now)
+
+			texts := sentTo(t, b, sender, user.ChatID)
+			if len(texts) != tt.wantN {
+				t.Fatalf("sent %q, want %d messages", texts, tt.wantN)
+			}
+			if tt.wantN > 0 && !strings.HasPrefix(texts[0], "Расписание на неделю с 04.11:") {
+				t.Errorf("digest %q is not for the week from 04.11", texts[0])
+			}
+		})
+	}
+}
+
+func TestWeekDigest(t *testing.T) {
+	b, _ := newTestBot(t)
+
+	monday := time.Date(2024, time.November, 4, 0, 0, 0, 0, mskLoc)
+	got := b.weekDigest(table.User{Group: testGroup}, monday)
+
+	for _, want := range []string{
+		"Понедельник 04.11 — выходной день (День народного единства), пар нет.",
+		"Вторник 05.11\n",
+		"Пятница 08.11\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("weekDigest() =\n%s\nwant it to contain %q", got, want)
+		}
+	}
+
+	// plain weekends are omitted
+	for _, day := range []string{"Суббота", "Воскресенье"} {
+		if strings.Contains(got, day) {
+			t.Errorf("weekDigest() =\n%s\nwant no %s", got, day)
+		}
+	}
+}
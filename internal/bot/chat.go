@@ -0,0 +1,265 @@
+package bot
+
+import (
+	"bot/internal/constant"
+	"bot/internal/entity/table"
+	"bot/internal/service"
+	"errors"
+	"fmt"
+	api "gopkg.in/telegram-bot-api.v4"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const chatHelp = `Я могу присылать расписание в этот чат.
+
+Администратор чата может привязать его к группе:
+/bind 04 74-20 — вся группа
+/bind 04 74-20 1 — только первая подгруппа
+/unbind — отвязать чат
+/daily — включить или выключить утреннюю рассылку
+
+Команды для всех:
+/today — расписание на сегодня
+/tomorrow — расписание на завтра
+/week — вся неделя
+/now — текущая и следующая пара`
+
+// handleChatMessage handles messages from group and supergroup chats. Chats
+// are bound to a study group, so nobody has to register.
+func (b *Bot) handleChatMessage(msg *api.Message) {
+	if msg.NewChatMembers != nil {
+		for _, member := range *msg.NewChatMembers {
+			if member.ID == b.Self.ID {
+				b.send(newMsgForUser(chatHelp, msg.Chat.ID, nil))
+			}
+		}
+		return
+	}
+
+	if !msg.IsCommand() {
+		return
+	}
+
+	switch msg.Command() {
+	case "start", "help":
+		b.send(newMsgForUser(chatHelp, msg.Chat.ID, nil))
+	case "bind":
+		b.bindChat(msg)
+	case "unbind":
+		b.unbindChat(msg)
+	case "daily":
+		b.changeChatSubscribe(msg)
+	case "today":
+		b.sendChatDay(msg.Chat.ID, 0)
+	case "tomorrow":
+		b.sendChatDay(msg.Chat.ID, 1)
+	case "week":
+		b.sendChatWeek(msg.Chat.ID)
+	case "now":
+		b.sendChatNow(msg.Chat.ID)
+	}
+}
+
+// isChatAdmin reports whether the user is an administrator or the creator of
+// the chat.
+func (b *Bot) isChatAdmin(chatID int64, userID int) bool {
+	member, err := b.GetChatMember(api.ChatConfigWithUser{ChatID: chatID, UserID: userID})
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get chat member error: %v", err.Error()))
+		return false
+	}
+
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+func (b *Bot) bindChat(msg *api.Message) {
+	if !b.isChatAdmin(msg.Chat.ID, msg.From.ID) {
+		b.send(newMsgForUser("Привязать чат может только администратор.", msg.Chat.ID, nil))
+		return
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+
+	subGroup := 0
+	if len(args) > 1 {
+		if sg, err := strconv.Atoi(args[len(args)-1]); err == nil && (sg == 1 || sg == 2) {
+			subGroup = sg
+			args = args[:len(args)-1]
+		}
+	}
+
+	group := strings.Join(args, " ")
+	if !b.schedule.VerifyGroup(group) {
+		b.send(newMsgForUser("Неверная группа! Пример: /bind 04 74-20 1", msg.Chat.ID, nil))
+		return
+	}
+
+	chat := table.Chat{
+		ID:         msg.Chat.ID,
+		Title:      msg.Chat.Title,
+		Group:      group,
+		SubGroup:   subGroup,
+		Subscribed: true,
+		BoundBy:    msg.From.ID,
+	}
+
+	if err := b.storage.SaveChat(chat); err != nil {
+		b.logger.Warn(fmt.Sprintf("bindChat save error: %v", err.Error()))
+		return
+	}
+
+	text := fmt.Sprintf("Чат привязан к группе %s.", group)
+	if subGroup != 0 {
+		text = fmt.Sprintf("Чат привязан к группе %s, %d подгруппа.", group, subGroup)
+	}
+
+	b.send(newMsgForUser(text+" Каждый будний день в 8:00 я буду присылать сюда расписание.", msg.Chat.ID, nil))
+}
+
+func (b *Bot) unbindChat(msg *api.Message) {
+	if !b.isChatAdmin(msg.Chat.ID, msg.From.ID) {
+		b.send(newMsgForUser("Отвязать чат может только администратор.", msg.Chat.ID, nil))
+		return
+	}
+
+	if err := b.storage.DeleteChat(msg.Chat.ID); err != nil {
+		b.logger.Warn(fmt.Sprintf("unbindChat delete error: %v", err.Error()))
+		return
+	}
+
+	b.send(newMsgForUser("Чат отвязан от группы.", msg.Chat.ID, nil))
+}
+
+func (b *Bot) changeChatSubscribe(msg *api.Message) {
+	if !b.isChatAdmin(msg.Chat.ID, msg.From.ID) {
+		b.send(newMsgForUser("Изменить рассылку может только администратор.", msg.Chat.ID, nil))
+		return
+	}
+
+	chat, ok := b.boundChat(msg.Chat.ID)
+	if !ok {
+		return
+	}
+
+	chat.Subscribed = !chat.Subscribed
+	if err := b.storage.SaveChat(chat); err != nil {
+		b.logger.Warn(fmt.Sprintf("changeChatSubscribe save error: %v", err.Error()))
+		return
+	}
+
+	if chat.Subscribed {
+		b.send(newMsgForUser("Утренняя рассылка включена.", chat.ID, nil))
+	} else {
+		b.send(newMsgForUser("Утренняя рассылка выключена.", chat.ID, nil))
+	}
+}
+
+// boundChat returns the binding of the chat. If the chat is not bound, the
+// chat is told how to bind it.
+func (b *Bot) boundChat(chatID int64) (table.Chat, bool) {
+	chat, err := b.storage.GetChatByID(chatID)
+	if err != nil {
+		if !errors.Is(err, constant.ErrChatNotFound) {
+			b.logger.Warn(fmt.Sprintf("get chat error: %v", err.Error()))
+			return chat, false
+		}
+
+		b.send(newMsgForUser("Чат еще не привязан к группе. Администратор может сделать это командой /bind 04 74-20", chatID, nil))
+		return chat, false
+	}
+
+	return chat, true
+}
+
+// sendChatDay sends the schedule of the day that is days after today.
+func (b *Bot) sendChatDay(chatID int64, days int) {
+	chat, ok := b.boundChat(chatID)
+	if !ok {
+		return
+	}
+
+	now := time.Now().In(mskLoc)
+	date := time.Date(now.Year(), now.Month(), now.Day()+days, 0, 0, 0, 0, mskLoc)
+
+	text, err := b.dateText(chat.Group, chat.SubGroup, date, true)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("sendChatDay error: %v", err.Error()))
+		return
+	}
+
+	b.send(newMsgForUser(text, chat.ID, nil))
+}
+
+func (b *Bot) sendChatWeek(chatID int64) {
+	chat, ok := b.boundChat(chatID)
+	if !ok {
+		return
+	}
+
+	w, err := b.schedule.GetWeekByGroup(chat.Group)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get week error: %v", err.Error()))
+		return
+	}
+
+	now := time.Now().In(mskLoc)
+	b.send(newMsgForUser(service.WeekToString(w, service.WeekIndex(now), chat.SubGroup, b.calendar.WeekOff(chat.Group, now)), chat.ID, nil))
+}
+
+func (b *Bot) sendChatNow(chatID int64) {
+	chat, ok := b.boundChat(chatID)
+	if !ok {
+		return
+	}
+
+	t := time.Now().In(mskLoc)
+	cd := b.calendar.Day(chat.Group, t)
+	if !cd.Study {
+		b.send(newMsgForUser("Сегодня "+cd.Off()+", пар нет 🎉", chat.ID, nil))
+		return
+	}
+
+	day, err := b.schedule.GetDayByGroup(chat.Group, weekdayToInt(cd.Weekday))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get day error: %v", err.Error()))
+		return
+	}
+
+	b.send(newMsgForUser(nowText(day, chat.SubGroup, b.bellsFor(chat.Group, t), t, mskLoc), chat.ID, nil))
+}
+
+// sendDailyToChats posts today's schedule to the subscribed chats at the
+// default hour, once a day.
+func (b *Bot) sendDailyToChats(now time.Time) {
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if !due(date.Add(table.DefaultDailyHour*time.Hour), now) {
+		return
+	}
+
+	chats, err := b.storage.GetSubscribedChats()
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("sendDailyToChats error: GetSubscribedChats error: %v", err.Error()))
+		return
+	}
+
+	for _, chat := range chats {
+		if !b.calendar.Day(chat.Group, date).Study {
+			continue
+		}
+
+		d := table.Delivery{ChatID: chat.ID, Kind: table.DeliveryChat, Date: date.Format(constant.DateLayout)}
+		if !b.ledger.claim(d, now) {
+			continue
+		}
+
+		text, err := b.dateText(chat.Group, chat.SubGroup, date, true)
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("sendDailyToChats error: %v", err.Error()))
+			continue
+		}
+
+		b.send(newMsgForUser("Расписание на сегодня\n\n"+text, chat.ID, nil))
+	}
+}
@@ -0,0 +1,272 @@
+package bot
+
+import (
+	"bot/internal/constant"
+	"bot/internal/entity/table"
+	"errors"
+	"fmt"
+	api "gopkg.in/telegram-bot-api.v4"
+	"strconv"
+	"strings"
+)
+
+// maxFollows is the maximum number of groups a user can follow besides the
+// primary one.
+const maxFollows = 5
+
+// view is the group whose schedule is shown: the primary group of the user or
+// one of the followed groups.
+type view struct {
+	followID int
+	group    string
+	subGroup int
+}
+
+func primaryView(user table.User) view {
+	return view{group: user.Group, subGroup: user.SubGroup}
+}
+
+func followView(f table.Follow) view {
+	return view{followID: f.ID, group: f.Group, subGroup: f.SubGroup}
+}
+
+// title returns the group name shown above the schedule of a followed group.
+func (v view) title() string {
+	if v.followID == 0 {
+		return ""
+	}
+
+	if v.subGroup != 0 {
+		return fmt.Sprintf("Группа %s, %d подгруппа\n", v.group, v.subGroup)
+	}
+
+	return fmt.Sprintf("Группа %s\n", v.group)
+}
+
+// followName is the short name of the followed group used on buttons.
+func followName(f table.Follow) string {
+	if f.SubGroup != 0 {
+		return fmt.Sprintf("%s (%d)", f.Group, f.SubGroup)
+	}
+
+	return f.Group
+}
+
+// userFollow returns the follow by its callback argument if it belongs to the
+// user.
+func (b *Bot) userFollow(user table.User, arg string) (table.Follow, bool) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("follow id error: %v", err.Error()))
+		return table.Follow{}, false
+	}
+
+	f, err := b.storage.GetFollowByID(id)
+	if err != nil {
+		if !errors.Is(err, constant.ErrFollowNotFound) {
+			b.logger.Warn(fmt.Sprintf("get follow error: %v", err.Error()))
+		}
+		return f, false
+	}
+
+	return f, f.UserID == user.ID
+}
+
+// viewFor returns the view of the follow given by the callback argument, the
+// primary group is shown if the argument is empty or wrong.
+func (b *Bot) viewFor(user table.User, arg string) view {
+	if arg == "" || arg == "0" {
+		return primaryView(user)
+	}
+
+	if f, ok := b.userFollow(user, arg); ok {
+		return followView(f)
+	}
+
+	return primaryView(user)
+}
+
+func (b *Bot) follows(user table.User) []table.Follow {
+	follows, err := b.storage.GetFollows(user.ID)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get follows error: %v", err.Error()))
+	}
+
+	return follows
+}
+
+func (b *Bot) handleFollowCommand(msg *api.Message) {
+	user, ok := b.commandUser(msg)
+	if !ok {
+		return
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+
+	subGroup := 0
+	if len(args) > 1 {
+		if sg, err := strconv.Atoi(args[len(args)-1]); err == nil && (sg == 1 || sg == 2) {
+			subGroup = sg
+			args = args[:len(args)-1]
+		}
+	}
+
+	group := strings.Join(args, " ")
+	if !b.schedule.VerifyGroup(group) {
+		b.send(newMsgForUser("Неверная группа! Пример: /follow 04 74-20 1", user.ChatID, &followsKeyboard))
+		return
+	}
+
+	follows := b.follows(user)
+	if len(follows) >= maxFollows {
+		b.send(newMsgForUser(fmt.Sprintf("Можно следить не больше чем за %d группами.", maxFollows), user.ChatID, &followsKeyboard))
+		return
+	}
+
+	for _, f := range follows {
+		if f.Group == group && f.SubGroup == subGroup {
+			b.send(newMsgForUser("Ты уже следишь за этой группой.", user.ChatID, &followsKeyboard))
+			return
+		}
+	}
+
+	err := b.storage.AddFollow(table.Follow{UserID: user.ID, Group: group, SubGroup: subGroup})
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("add follow error: %v", err.Error()))
+		return
+	}
+
+	b.showFollows(user)
+}
+
+func (b *Bot) showFollows(user table.User) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Основная группа: %s", user.Group))
+	if user.SubGroup != 0 {
+		sb.WriteString(fmt.Sprintf(", %d подгруппа", user.SubGroup))
+	}
+	sb.WriteString("\n\n")
+
+	follows := b.follows(user)
+	if len(follows) == 0 {
+		sb.WriteString("Ты пока не следишь за другими группами.\n")
+	} else {
+		sb.WriteString("Ты следишь за группами:\n")
+	}
+
+	var rows [][]api.InlineKeyboardButton
+	for _, f := range follows {
+		sb.WriteString(fmt.Sprintf("• %s%s\n", followName(f), followFlags(f)))
+		rows = append(rows, api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData(followName(f), fmt.Sprintf("%s::%d", followOpen, f.ID)),
+		))
+	}
+
+	sb.WriteString("\nЧтобы добавить группу, напиши /follow и номер группы, например: /follow 04 74-20 1")
+
+	rows = append(rows, followsKeyboard.InlineKeyboard...)
+	keyboard := api.NewInlineKeyboardMarkup(rows...)
+
+	b.send(newMsgForUser(sb.String(), user.ChatID, &keyboard))
+}
+
+func followFlags(f table.Follow) string {
+	var flags []string
+	if f.Daily {
+		flags = append(flags, "расписание")
+	}
+	if f.Pair {
+		flags = append(flags, "пары")
+	}
+
+	if len(flags) == 0 {
+		return ""
+	}
+
+	return " — " + strings.Join(flags, ", ")
+}
+
+func (b *Bot) showFollow(user table.User, f table.Follow) {
+	onOff := func(on bool) string {
+		if on {
+			return "вкл"
+		}
+		return "выкл"
+	}
+
+	id := strconv.Itoa(f.ID)
+	keyboard := api.NewInlineKeyboardMarkup(
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Расписание", fmt.Sprintf("%s::-1::0::%s", schedule, id)),
+			api.NewInlineKeyboardButtonData("Сделать основной", followPrimary+"::"+id),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Отправка расписания: "+onOff(f.Daily), followDaily+"::"+id),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Отправка пар: "+onOff(f.Pair), followPair+"::"+id),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Не следить", unfollow+"::"+id),
+			api.NewInlineKeyboardButtonData("Назад", followList),
+		),
+	)
+
+	b.send(newMsgForUser("Группа "+followName(f), user.ChatID, &keyboard))
+}
+
+// handleFollowAction handles the buttons of a followed group.
+func (b *Bot) handleFollowAction(user table.User, action string, arg string) {
+	f, ok := b.userFollow(user, arg)
+	if !ok {
+		b.showFollows(user)
+		return
+	}
+
+	switch action {
+	case followOpen:
+		b.showFollow(user, f)
+		return
+	case followDaily:
+		f.Daily = !f.Daily
+	case followPair:
+		f.Pair = !f.Pair
+	case followPrimary:
+		b.makePrimary(user, f)
+		return
+	case unfollow:
+		if err := b.storage.DeleteFollow(f.ID); err != nil {
+			b.logger.Warn(fmt.Sprintf("delete follow error: %v", err.Error()))
+		}
+		b.showFollows(user)
+		return
+	}
+
+	if err := b.storage.SaveFollow(f); err != nil {
+		b.logger.Warn(fmt.Sprintf("save follow error: %v", err.Error()))
+	}
+
+	b.showFollow(user, f)
+}
+
+// makePrimary swaps the primary group of the user with the followed one,
+// subscriptions are swapped along with the groups and the headman role of the
+// former group is lost.
+func (b *Bot) makePrimary(user table.User, f table.Follow) {
+	user.Group, f.Group = f.Group, user.Group
+	user.SubGroup, f.SubGroup = f.SubGroup, user.SubGroup
+	user.Subscribed, f.Daily = f.Daily, user.Subscribed
+	user.SubscribedPair, f.Pair = f.Pair, user.SubscribedPair
+	user.Headman = false
+
+	if err := b.saveUser(user); err != nil {
+		b.logger.Warn(fmt.Sprintf("makePrimary save user error: %v", err.Error()))
+		return
+	}
+
+	if err := b.storage.SaveFollow(f); err != nil {
+		b.logger.Warn(fmt.Sprintf("makePrimary save follow error: %v", err.Error()))
+	}
+
+	b.showFollows(user)
+}
@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"bot/internal/bells"
 	"bot/internal/constant"
 	"bot/internal/entity/table"
 	"bot/internal/service"
@@ -15,11 +16,37 @@ import (
 
 // handleMessage handles commands.
 func (b *Bot) handleCommand(msg *api.Message) {
+	if b.handleAdminCommand(msg) {
+		return
+	}
+
 	switch cmd := msg.Command(); cmd {
 	case "start":
 		b.handleStart(msg)
-	case "unsub_all_from_pairs":
-		b.handleUnsubAllFromPairs()
+	case "week":
+		b.handleWeekCommand(msg)
+	case "follow":
+		b.handleFollowCommand(msg)
+	case "note":
+		b.handleNoteCommand(msg)
+	case "event":
+		b.handleEventCommand(msg)
+	case "notes":
+		b.handleNotesCommand(msg)
+	case "hw":
+		b.handleHomeworkCommand(msg)
+	case "announce":
+		b.handleAnnounceCommand(msg)
+	case "pin":
+		b.handlePinCommand(msg)
+	case "members":
+		b.handleMembersCommand(msg)
+	case "now":
+		b.handleNowCommand(msg)
+	case "bells":
+		b.handleBellsCommand(msg)
+	case "tz":
+		b.handleTimeZoneCommand(msg)
 	}
 }
 
@@ -41,6 +68,13 @@ func (b *Bot) handleMessage(msg *api.Message) {
 		b.addGroup(user, group)
 		return
 	}
+
+	if date, ok := service.ParseDate(msg.Text, time.Now().In(mskLoc)); ok {
+		b.send(b.dateMessage(user, primaryView(user), date, "День: %s %s"))
+		return
+	}
+
+	b.send(newMsgForUser(service.DateHint, user.ChatID, &toScheduleKeyboard))
 }
 
 // handleStart handles start command.
@@ -56,23 +90,19 @@ func (b *Bot) handleStart(msg *api.Message) {
 	}
 }
 
-func (b *Bot) handleUnsubAllFromPairs() {
-	b.mu.RLock()
-	for id := range b.subscribers {
-		user, err := b.storage.GetUserByID(id)
-		if err != nil {
-			b.logger.Warn(fmt.Sprintf("handleUnsubAllFromPairs error: GetUserByID error: %v", err.Error()))
-			continue
-		}
-
-		user.SubscribedPair = false
-		err = b.storage.SaveUser(user)
-		if err != nil {
-			b.logger.Warn(fmt.Sprintf("handleUnsubAllFromPairs error: SaveUser error: %v", err.Error()))
-			continue
+func (b *Bot) handleUnsubAllFromPairs(admin table.User, _ string) {
+	for _, users := range b.registry.pairByGroup() {
+		for _, user := range users {
+			user.SubscribedPair = false
+			err := b.saveUser(user)
+			if err != nil {
+				b.logger.Warn(fmt.Sprintf("handleUnsubAllFromPairs error: SaveUser error: %v", err.Error()))
+				continue
+			}
 		}
 	}
-	b.mu.RUnlock()
+
+	b.send(newMsgForUser("Все отписаны от пар.", admin.ChatID, nil))
 }
 
 func (b *Bot) handleGroup(msg *api.Message) {
@@ -182,20 +212,66 @@ func (b *Bot) handleCallbackQuery(query *api.CallbackQuery) {
 		b.showDailyScheduleSubscribe(user)
 	case sendPair:
 		b.showPairSubscribe(user)
+	case sendEvening:
+		b.showEveningSubscribe(user)
+	case sendWeekly:
+		b.showWeeklySubscribe(user)
+	case reminders:
+		b.showReminders(user)
+	case remindBefore:
+		b.changeRemindBefore(user, split[1])
+	case remindMode:
+		b.changeRemindMode(user, split[1])
+	case dailyTime:
+		if len(split) == 1 {
+			b.showDailyTime(user)
+		} else {
+			b.changeDailyTime(user, split[1])
+		}
+	case timeZone:
+		if len(split) == 1 {
+			b.showTimeZone(user)
+		} else {
+			b.changeTimeZone(user, split[1])
+		}
 	case changeDailySubscribe:
 		b.changeDailySubscribe(user)
 	case changePairSubscribe:
 		b.changePairSubscribe(user)
+	case changeEveningSubscribe:
+		b.changeEveningSubscribe(user)
+	case changeWeeklySubscribe:
+		b.changeWeeklySubscribe(user)
 	case info:
 		b.showInfo(user)
 	case silence:
 		b.silence(user)
-	case schedule:
+	case followList:
+		b.showFollows(user)
+	case followOpen, followDaily, followPair, followPrimary, unfollow:
+		b.handleFollowAction(user, text, split[1])
+	case deleteNote, deleteEvent:
+		b.deleteNoteOrEvent(user, text, split[1])
+	case deleteHomework:
+		b.deleteHomework(user, split[1])
+	case deleteNotice:
+		b.deleteNotice(user, split[1])
+	case mute:
 		if len(split) == 1 {
-			split = append(split, "-1")
+			b.showMute(user)
+		} else {
+			b.handleMute(user, split[1])
 		}
-
-		b.send(b.handleSchedule(split[1], query.Message.MessageID, user))
+	case unmute:
+		b.unmute(user)
+	case quiet:
+		b.changeQuietHours(user, split[1])
+	case week:
+		b.send(b.handleWeek(query.Message.MessageID, user))
+	case nowView:
+		b.send(b.handleNow(user))
+	case schedule:
+		b.send(b.handleSchedule(split[1:], query.Message.MessageID, user))
 	}
 }
 
@@ -230,51 +306,160 @@ func weekdayToInt(w time.Weekday) int {
 	return -1
 }
 
-func (b *Bot) handleSchedule(text string, msgID int, user table.User) (msg api.Chattable) {
-	needNew := false
-	weekDay := time.Now().Weekday()
+// weekStart returns the midnight of the Monday of the week t belongs to.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
 
-	offset, err := strconv.Atoi(text)
-	if err != nil {
-		b.logger.Warn(fmt.Sprintf("get offset error: %v", err.Error()))
-	} else if offset == -1 {
-		offset = weekdayToInt(weekDay)
-		needNew = true
+// nearestDay returns the study day to show by default: today on weekdays and
+// Monday of the next week on weekends.
+func nearestDay(now time.Time) (day int, weekShift int) {
+	if wd := now.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return 0, 1
 	}
-	monthDay := time.Now().Add(time.Hour * 24 * time.Duration(offset-int(weekDay)+1)).Day()
 
-	defer func() {
-		if needNew {
-			msg = newMsgForUser(text, user.ChatID, &scheduleKeyboard)
-		} else {
-			msg = editMsgForUser(text, user.ChatID, msgID, scheduleKeyboard)
+	return weekdayToInt(now.Weekday()), 0
+}
+
+// handleSchedule shows the schedule of one day. args are the callback
+// arguments: the day offset (or "-1" for a new message with the nearest day,
+// or today to jump back), an optional week shift relative to the current week
+// and an optional id of the followed group to show.
+func (b *Bot) handleSchedule(args []string, msgID int, user table.User) api.Chattable {
+	now := time.Now().In(mskLoc)
+
+	needNew := len(args) == 0 || args[0] == "-1"
+	offset, weekShift := nearestDay(now)
+
+	if !needNew && args[0] != today {
+		var err error
+		if offset, err = strconv.Atoi(args[0]); err != nil {
+			b.logger.Warn(fmt.Sprintf("get offset error: %v", err.Error()))
 		}
-	}()
 
-	day, err := b.schedule.GetDayByGroup(user.Group, offset)
-	if err != nil {
-		b.logger.Warn(fmt.Sprintf("get day error: %v", err.Error()))
-		text = "Ошибка получения расписания. Попробуй еще раз изменить группу в настройках. Сообщи об этом @gasayminajj ."
-		return msg
+		weekShift = 0
+		if len(args) > 1 {
+			if weekShift, err = strconv.Atoi(args[1]); err != nil {
+				b.logger.Warn(fmt.Sprintf("get week shift error: %v", err.Error()))
+			}
+		}
 	}
 
-	var sb strings.Builder
-	if len(day) > 0 {
-		if needNew {
-			sb.WriteString(fmt.Sprintf("Твое ближайшее расписание на %s %d:\n\n", toDay(offset), monthDay))
-		} else {
-			sb.WriteString(fmt.Sprintf("День: %s %d\n\n", toDay(offset), monthDay))
+	v := primaryView(user)
+	if len(args) > 2 {
+		v = b.viewFor(user, args[2])
+	}
+
+	if needNew {
+		return b.dayMessage(user, v, offset, weekShift, 0, "Твое ближайшее расписание на %s %s:")
+	}
+
+	return b.dayMessage(user, v, offset, weekShift, msgID, "День: %s %s")
+}
+
+// dayMessage renders the day of the week shifted by weekShift from the current
+// one. title is formatted with the weekday name and the date. A zero msgID
+// sends a new message, otherwise the message is edited in place.
+func (b *Bot) dayMessage(user table.User, v view, offset, weekShift, msgID int, title string) api.Chattable {
+	date := weekStart(time.Now().In(mskLoc)).AddDate(0, 0, weekShift*7+offset)
+	keyboard := newScheduleKeyboard(offset, weekShift, v.followID, b.follows(user))
+
+	var body string
+	if cd := b.calendar.Day(v.group, date); cd.Study {
+		var err error
+		body, err = b.renderDay(v.group, v.subGroup, weekdayToInt(cd.Weekday), b.pairNotes(user, v, date), false)
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("get day error: %v", err.Error()))
+			body = "Ошибка получения расписания. Попробуй еще раз изменить группу в настройках. Сообщи об этом @gasayminajj ."
 		}
+	} else {
+		body = "Пар нет: " + cd.Off() + ".\n"
+	}
+
+	if v.followID == 0 {
+		body += b.eventsText(user, date)
+	}
+
+	text := v.title() + fmt.Sprintf(title, toDay(offset), date.Format("02.01")) + "\n\n" + b.noticesText(v.group, date) + body
+
+	if msgID == 0 {
+		return newMsgForUser(text, user.ChatID, &keyboard)
+	}
+
+	return editMsgForUser(text, user.ChatID, msgID, keyboard)
+}
+
+// dateMessage renders the schedule of the given date as a new message. title is
+// formatted with the weekday name and the date.
+func (b *Bot) dateMessage(user table.User, v view, date time.Time, title string) api.Chattable {
+	wd := date.Weekday()
+	if cd := b.calendar.Day(v.group, date); !cd.Study {
+		return newMsgForUser(
+			v.title()+fmt.Sprintf("%s %s — %s, пар нет.", toDay(int(wd+6)%7), date.Format("02.01"), cd.Off()),
+			user.ChatID, &toScheduleKeyboard,
+		)
+	}
+
+	now := time.Now().In(mskLoc)
+	weekShift := int(weekStart(date).Sub(weekStart(now)).Hours()/24) / 7
+
+	return b.dayMessage(user, v, int(wd+6)%7, weekShift, 0, title)
+}
+
+// dateText renders the schedule of the group for the date, both is passed to
+// renderDay.
+func (b *Bot) dateText(group string, subGroup int, date time.Time, both bool) (string, error) {
+	dayName := toDay(int(date.Weekday()+6) % 7)
+
+	cd := b.calendar.Day(group, date)
+	if !cd.Study {
+		return fmt.Sprintf("%s %s — %s, пар нет.", dayName, date.Format("02.01"), cd.Off()), nil
 	}
 
+	body, err := b.renderDay(group, subGroup, weekdayToInt(cd.Weekday), nil, both)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s %s\n\n%s%s", dayName, date.Format("02.01"), b.noticesText(group, date), body), nil
+}
+
+// renderDay renders the pairs of the group's day for the given subgroup, notes
+// are shown under the pairs by their indexes. both shows the pairs of both
+// subgroups when no subgroup is chosen, it is meant for the views shared with
+// the whole group.
+func (b *Bot) renderDay(group string, subGroup int, offset int, notes map[int][]string, both bool) (string, error) {
+	day, err := b.schedule.GetDayByGroup(group, offset)
+	if err != nil {
+		return "", err
+	}
+
+	return dayText(day, subGroup, notes, both), nil
+}
+
+// dayText renders the pairs of the day, see renderDay.
+func dayText(day service.WorkDay, subGroup int, notes map[int][]string, both bool) string {
 	if len(day) == 0 {
-		sb.WriteString("Нет пар на этот день")
-		text = sb.String()
-		return msg
+		return "Нет пар на этот день"
 	}
 
+	var sb strings.Builder
 	for i, pairE := range day {
-		actualPair, err := findGroup(pairE, user.SubGroup)
+		// no subgroup is chosen, show the pairs of both of them
+		if both && subGroup == 0 && len(pairE) > 0 && pairE[0].Group != 0 {
+			sb.WriteString(fmt.Sprintf("№%d\n", i+1))
+			for _, p := range pairE {
+				sb.WriteString(fmt.Sprintf("%d подгруппа: %s, каб. %s, %s\n", p.Group, p.Subject, p.Room, p.Teacher))
+			}
+			for _, note := range notes[i] {
+				sb.WriteString(note + "\n")
+			}
+			sb.WriteString("\n")
+			continue
+		}
+
+		actualPair, err := findGroup(pairE, subGroup)
 		if err != nil {
 			switch err {
 			case ErrGroupNotFound:
@@ -293,40 +478,249 @@ func (b *Bot) handleSchedule(text string, msgID int, user table.User) (msg api.C
 		} else {
 			sb.WriteString(
 				fmt.Sprintf(
-					"№%d\nПредмет: %s\nКабинет: %s\nПреподаватель: %s\n\n",
+					"№%d\nПредмет: %s\nКабинет: %s\nПреподаватель: %s\n",
 					i+1, actualPair.Subject, actualPair.Room, actualPair.Teacher,
 				),
 			)
+			for _, note := range notes[i] {
+				sb.WriteString(note + "\n")
+			}
+			sb.WriteString("\n")
 		}
 	}
 
-	text = sb.String()
+	return sb.String()
+}
 
-	return msg
+// commandUser returns the registered user who sent the command. Unknown
+// users are registered and users without a group are asked for it, in both
+// cases false is returned.
+func (b *Bot) commandUser(msg *api.Message) (table.User, bool) {
+	user, err := b.storage.GetUserByID(msg.From.ID)
+	if err != nil {
+		if !errors.Is(err, constant.ErrUserNotFound) {
+			b.logger.Warn(fmt.Sprintf("get user error: %v", err.Error()))
+			return user, false
+		}
+
+		b.register(msg.Chat.ID, msg.From)
+		return user, false
+	}
+
+	if user.Group == "" {
+		b.suggestGroup(user)
+		return user, false
+	}
+
+	return user, true
 }
 
-func (b *Bot) handleNextPair(user table.User, offset int) (msg api.Chattable, err error) {
-	//day :=
+func (b *Bot) handleWeekCommand(msg *api.Message) {
+	if user, ok := b.commandUser(msg); ok {
+		b.send(b.handleWeek(0, user))
+	}
+}
+
+func (b *Bot) handleNowCommand(msg *api.Message) {
+	if user, ok := b.commandUser(msg); ok {
+		b.send(b.handleNow(user))
+	}
+}
+
+// handleBellsCommand shows the pair times of the user's campus on the date,
+// today by default: /bells, /bells завтра.
+func (b *Bot) handleBellsCommand(msg *api.Message) {
+	user, ok := b.commandUser(msg)
+	if !ok {
+		return
+	}
+
+	date := time.Now().In(mskLoc)
+	if arg := msg.CommandArguments(); strings.TrimSpace(arg) != "" {
+		if date, ok = service.ParseDate(arg, date); !ok {
+			b.send(newMsgForUser("Не понял дату. Например: /bells завтра", user.ChatID, nil))
+			return
+		}
+	}
+
+	b.send(newMsgForUser(b.bellsText(user.Group, date, userLoc(user)), user.ChatID, &toScheduleKeyboard))
+}
+
+// bellsText lists the pair times of the group's campus on the date, the times
+// are shown in loc.
+func (b *Bot) bellsText(group string, date time.Time, loc *time.Location) string {
+	var sb strings.Builder
 
-	day, err := b.schedule.GetDayByGroup(user.Group, weekdayToInt(time.Now().Weekday()))
+	sb.WriteString("Звонки на " + date.Format("02.01"))
+	if campus := b.schedule.Campus(group); campus != "" {
+		sb.WriteString(" (" + campus + ")")
+	}
+	sb.WriteString(":\n")
+
+	for i, bell := range b.bellsFor(group, date) {
+		sb.WriteString(fmt.Sprintf("%d. %s – %s\n", i+1, localTime(date, bell.Start, loc), localTime(date, bell.End, loc)))
+	}
+
+	if loc != mskLoc {
+		sb.WriteString("\nВремя по твоему часовому поясу.")
+	}
+
+	return sb.String()
+}
+
+// handleWeek renders the whole week of the user's group. A zero msgID sends
+// a new message, otherwise the message is edited in place.
+func (b *Bot) handleWeek(msgID int, user table.User) api.Chattable {
+	var text string
+
+	w, err := b.schedule.GetWeekByGroup(user.Group)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get week error: %v", err.Error()))
+		text = "Ошибка получения расписания. Попробуй еще раз изменить группу в настройках. Сообщи об этом @gasayminajj ."
+	} else {
+		now := time.Now().In(mskLoc)
+		text = service.WeekToString(w, service.WeekIndex(now), user.SubGroup, b.calendar.WeekOff(user.Group, now))
+	}
+
+	day, weekShift := nearestDay(time.Now().In(mskLoc))
+	keyboard := newScheduleKeyboard(day, weekShift, 0, nil)
+	if msgID == 0 {
+		return newMsgForUser(text, user.ChatID, &keyboard)
+	}
+
+	return editMsgForUser(text, user.ChatID, msgID, keyboard)
+}
+
+// handleNow shows the current pair with the time left, the next pair with its
+// start time and room, and the gaps between pairs.
+func (b *Bot) handleNow(user table.User) api.Chattable {
+	t := time.Now().In(mskLoc)
+
+	cd := b.calendar.Day(user.Group, t)
+	if !cd.Study {
+		return newMsgForUser("Сегодня "+cd.Off()+", пар нет 🎉", user.ChatID, &nowKeyboard)
+	}
+
+	day, err := b.schedule.GetDayByGroup(user.Group, weekdayToInt(cd.Weekday))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get day error: %v", err.Error()))
+		return newMsgForUser("Ошибка получения расписания. Попробуй еще раз изменить группу в настройках.", user.ChatID, &nowKeyboard)
+	}
+
+	return newMsgForUser(nowText(day, user.SubGroup, b.bellsFor(user.Group, t), t, userLoc(user)), user.ChatID, &nowKeyboard)
+}
+
+// nowText describes the day of the subgroup with the bells at the moment t,
+// the times are shown in loc.
+func nowText(day service.WorkDay, subGroup int, bs bells.Schedule, t time.Time, loc *time.Location) string {
+	minute := t.Hour()*60 + t.Minute()
+
+	var pairs []int
+	for i, pairE := range day {
+		if i >= len(bs) {
+			break
+		}
+		if _, err := findGroup(pairE, subGroup); err == nil {
+			pairs = append(pairs, i)
+		}
+	}
+
+	if len(pairs) == 0 {
+		return "Сегодня пар нет 🎉"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Сейчас %s\n\n", t.In(loc).Format("15:04")))
+
+	current, next, prevEnd := -1, -1, -1
+	for _, i := range pairs {
+		switch {
+		case bs[i].End <= minute:
+			prevEnd = bs[i].End
+		case bs[i].Start <= minute:
+			current = i
+		case next == -1:
+			next = i
+		}
+	}
+
+	if current != -1 {
+		p, _ := findGroup(day[current], subGroup)
+		sb.WriteString(fmt.Sprintf(
+			"Идет пара №%d: %s\nКабинет: %s\nДо конца: %s\n\n",
+			current+1, p.Subject, p.Room, formatDuration(bs[current].End-minute),
+		))
+	} else if next != -1 && prevEnd != -1 {
+		sb.WriteString(fmt.Sprintf("Сейчас окно %s\n\n", formatDuration(bs[next].Start-minute)))
+	}
+
+	if next == -1 {
+		if current == -1 {
+			sb.WriteString("Пары на сегодня закончились 🎉")
+		} else {
+			sb.WriteString("Это последняя пара на сегодня.")
+		}
+		return sb.String()
+	}
+
+	p, _ := findGroup(day[next], subGroup)
+	sb.WriteString(fmt.Sprintf(
+		"Следующая пара №%d в %s (через %s): %s\nКабинет: %s\n",
+		next+1, localTime(t, bs[next].Start, loc), formatDuration(bs[next].Start-minute), p.Subject, p.Room,
+	))
+
+	if current != -1 && next > current+1 {
+		sb.WriteString(fmt.Sprintf("\nПосле пары окно %s", formatDuration(bs[next].Start-bs[current].End)))
+	}
+
+	return sb.String()
+}
+
+// formatDuration formats minutes as "1ч 40м".
+func formatDuration(m int) string {
+	if m < 60 {
+		return fmt.Sprintf("%dм", m)
+	}
+
+	if m%60 == 0 {
+		return fmt.Sprintf("%dч", m/60)
+	}
+
+	return fmt.Sprintf("%dч %dм", m/60, m%60)
+}
+
+func (b *Bot) handleNextPair(user table.User, v view, offset int) (msg api.Chattable, err error) {
+	now := time.Now().In(mskLoc)
+
+	cd := b.calendar.Day(v.group, now)
+	if !cd.Study {
+		return nil, ErrNoPair
+	}
+
+	day, err := b.schedule.GetDayByGroup(v.group, weekdayToInt(cd.Weekday))
 	if err != nil {
 		b.logger.Warn(fmt.Sprintf("get day error: %v", err.Error()))
 		return nil, err
 	}
 
-	if len(day) == 0 || len(day) <= offset {
+	bs := b.bellsFor(v.group, now)
+	if len(day) == 0 || len(day) <= offset || offset >= len(bs) {
 		return nil, ErrNoPair
 	}
 
-	actualPair, err := findGroup(day[offset], user.SubGroup)
+	actualPair, err := findGroup(day[offset], v.subGroup)
 	if err != nil {
 		b.logger.Warn(fmt.Sprintf("find group error: %v", err.Error()))
 		return nil, err
 	}
 
-	var text = fmt.Sprintf(
-		"Следующая пара: %s\nПреподаватель: %s\nКабинет: %s\n\n",
-		actualPair.Subject, actualPair.Teacher, actualPair.Room)
+	var text = v.title() + fmt.Sprintf(
+		"Следующая пара №%d в %s: %s\nПреподаватель: %s\nКабинет: %s\n\n",
+		offset+1, localTime(now, bs[offset].Start, userLoc(user)), actualPair.Subject, actualPair.Teacher, actualPair.Room)
+
+	for _, note := range b.pairNotes(user, v, now)[offset] {
+		text += note + "\n"
+	}
 
 	return newMsgForUser(text, user.ChatID, &nextPairKeyboard), nil
 }
@@ -340,6 +734,8 @@ func (b *Bot) register(chatID int64, from *api.User) {
 		Admin:          false,
 		Subscribed:     true,
 		SubscribedPair: false,
+		DailyHour:      table.DefaultDailyHour,
+		RemindBefore:   table.DefaultRemindBefore,
 	}
 	err := b.storage.AddUser(us)
 	if err != nil {
@@ -356,9 +752,11 @@ func (b *Bot) register(chatID int64, from *api.User) {
 }
 
 func (b *Bot) suggestGroup(user table.User) {
+	// the headman role is given for the group, it is lost along with it
 	user.Group = ""
+	user.Headman = false
 
-	err := b.storage.SaveUser(user)
+	err := b.saveUser(user)
 	if err != nil {
 		b.logger.Warn(fmt.Sprintf("suggestGroup save error: %v", err.Error()))
 	}
@@ -376,7 +774,7 @@ func (b *Bot) showThanksForRegistration(user table.User) {
 }
 
 func (b *Bot) showDailyScheduleSubscribe(user table.User) {
-	var text = "Я могу присылать расписание каждый будний день в 8:00. \n \n"
+	var text = fmt.Sprintf("Я могу присылать расписание %s. \n \n", dailyTimeText(user))
 	if user.Subscribed {
 		text += "Отписаться?"
 	} else {
@@ -386,8 +784,51 @@ func (b *Bot) showDailyScheduleSubscribe(user table.User) {
 	b.send(newMsgForUser(text, user.ChatID, &submitDailyScheduleSubscribeKeyboard))
 }
 
+// dailyTimeText describes when the user gets the daily schedule.
+func dailyTimeText(user table.User) string {
+	if user.DailyEvening {
+		return fmt.Sprintf("накануне вечером в %d:00", eveningHour)
+	}
+
+	hour := user.DailyHour
+	if hour == 0 {
+		hour = table.DefaultDailyHour
+	}
+
+	return fmt.Sprintf("каждый будний день в %d:00", hour)
+}
+
+func (b *Bot) showDailyTime(user table.User) {
+	text := fmt.Sprintf("Сейчас я присылаю расписание %s.\n\nКогда присылать?", dailyTimeText(user))
+	b.send(newMsgForUser(text, user.ChatID, &dailyTimeKeyboard))
+}
+
+// changeDailyTime sets the hour of the daily schedule, or the evening before
+// if value is evening.
+func (b *Bot) changeDailyTime(user table.User, value string) {
+	if value == evening {
+		user.DailyEvening = true
+	} else {
+		hour, err := strconv.Atoi(value)
+		if err != nil || hour < 0 || hour > 23 {
+			b.logger.Warn(fmt.Sprintf("changeDailyTime wrong hour: %v", value))
+			return
+		}
+
+		user.DailyEvening = false
+		user.DailyHour = hour
+	}
+
+	err := b.saveUser(user)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("changeDailyTime save error: %v", err.Error()))
+	}
+
+	b.send(newMsgForUser(fmt.Sprintf("Готово! Буду присылать расписание %s.", dailyTimeText(user)), user.ChatID, &toScheduleKeyboard))
+}
+
 func (b *Bot) showPairSubscribe(user table.User) {
-	var text = "Я могу присылать напоминание о каждой паре на перемене. \n \n"
+	var text = fmt.Sprintf("Я могу присылать напоминание %s за %d мин. до начала. \n \n", remindModeText(user.RemindMode), user.RemindBefore)
 	if user.SubscribedPair {
 		text += "Отписаться?"
 	} else {
@@ -397,12 +838,100 @@ func (b *Bot) showPairSubscribe(user table.User) {
 	b.send(newMsgForUser(text, user.ChatID, &submitPairSubscribeKeyboard))
 }
 
+func (b *Bot) showEveningSubscribe(user table.User) {
+	var text = fmt.Sprintf("Я могу присылать расписание на завтра накануне в %d:00 — с объявлениями старосты, домашними заданиями и твоими событиями. \n \n", eveningHour)
+	if user.SubscribedEvening {
+		text += "Отписаться?"
+	} else {
+		text += "Подписаться?"
+	}
+
+	b.send(newMsgForUser(text, user.ChatID, &submitEveningSubscribeKeyboard))
+}
+
+func (b *Bot) showWeeklySubscribe(user table.User) {
+	var text = fmt.Sprintf("Я могу присылать в воскресенье в %d:00 расписание на следующую неделю с выходными и объявлениями. \n \n", eveningHour)
+	if user.SubscribedWeekly {
+		text += "Отписаться?"
+	} else {
+		text += "Подписаться?"
+	}
+
+	b.send(newMsgForUser(text, user.ChatID, &submitWeeklySubscribeKeyboard))
+}
+
+// maxRemindBefore is the maximum reminder lead time in minutes.
+const maxRemindBefore = 30
+
+func remindModeText(mode int) string {
+	switch mode {
+	case table.RemindFirst:
+		return "только о первой паре"
+	case table.RemindRoomChange:
+		return "только когда меняется кабинет"
+	}
+
+	return "о каждой паре"
+}
+
+func (b *Bot) showReminders(user table.User) {
+	text := fmt.Sprintf(
+		"Напоминаю %s за %d мин. до начала.\n\nЗа сколько минут напоминать и о каких парах?",
+		remindModeText(user.RemindMode), user.RemindBefore,
+	)
+
+	b.send(newMsgForUser(text, user.ChatID, &remindersKeyboard))
+}
+
+func (b *Bot) changeRemindBefore(user table.User, value string) {
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < 0 || minutes > maxRemindBefore {
+		b.logger.Warn(fmt.Sprintf("changeRemindBefore wrong value: %v", value))
+		return
+	}
+
+	user.RemindBefore = minutes
+	if err := b.saveUser(user); err != nil {
+		b.logger.Warn(fmt.Sprintf("changeRemindBefore save error: %v", err.Error()))
+	}
+
+	b.showReminders(user)
+}
+
+func (b *Bot) changeRemindMode(user table.User, value string) {
+	mode, err := strconv.Atoi(value)
+	if err != nil || mode < table.RemindAll || mode > table.RemindRoomChange {
+		b.logger.Warn(fmt.Sprintf("changeRemindMode wrong value: %v", value))
+		return
+	}
+
+	user.RemindMode = mode
+	if err := b.saveUser(user); err != nil {
+		b.logger.Warn(fmt.Sprintf("changeRemindMode save error: %v", err.Error()))
+	}
+
+	b.showReminders(user)
+}
+
 func (b *Bot) showSuccess(user table.User) {
 	b.send(newMsgForUser("Успешно!", user.ChatID, &toScheduleKeyboard))
 }
 
 func (b *Bot) showSettings(user table.User) {
-	b.send(newMsgForUser("Настройки:", user.ChatID, &settingsKeyboard))
+	status := muteStatus(user, time.Now().In(mskLoc))
+	if status == "" {
+		b.send(newMsgForUser("Настройки:", user.ChatID, &settingsKeyboard))
+		return
+	}
+
+	keyboard := settingsKeyboard
+	if time.Now().Before(user.SilenceUntil) {
+		keyboard = api.NewInlineKeyboardMarkup(
+			append([][]api.InlineKeyboardButton{unmuteKeyboard.InlineKeyboard[0]}, settingsKeyboard.InlineKeyboard...)...,
+		)
+	}
+
+	b.send(newMsgForUser("Настройки:\n\n"+status, user.ChatID, &keyboard))
 }
 
 func (b *Bot) addGroup(user table.User, group string) {
@@ -412,7 +941,7 @@ func (b *Bot) addGroup(user table.User, group string) {
 	}
 
 	user.Group = group
-	err := b.storage.SaveUser(user)
+	err := b.saveUser(user)
 	if err != nil {
 		b.logger.Warn(fmt.Sprintf("addGroup save error: %v", err.Error()))
 	}
@@ -428,7 +957,7 @@ func (b *Bot) addSubGroup(user table.User, subGroup string) {
 	}
 
 	user.SubGroup = subGroupInt
-	err = b.storage.SaveUser(user)
+	err = b.saveUser(user)
 	if err != nil {
 		b.logger.Warn(fmt.Sprintf("addSubGroup save error: %v", err.Error()))
 	}
@@ -436,16 +965,14 @@ func (b *Bot) addSubGroup(user table.User, subGroup string) {
 	b.showThanksForRegistration(user)
 }
 
+// send queues the message, it is sent within the Telegram limits.
 func (b *Bot) send(c api.Chattable) {
-	_, err := b.Send(c)
-	if err != nil {
-		b.logger.Warn(fmt.Sprintf("send error: %v", err.Error()))
-	}
+	b.broadcast.Send(c)
 }
 
 func (b *Bot) changeDailySubscribe(user table.User) {
 	user.Subscribed = !user.Subscribed
-	err := b.storage.SaveUser(user)
+	err := b.saveUser(user)
 	if err != nil {
 		b.logger.Warn(fmt.Sprintf("changeSubscribe save error: %v", err.Error()))
 	}
@@ -455,7 +982,27 @@ func (b *Bot) changeDailySubscribe(user table.User) {
 
 func (b *Bot) changePairSubscribe(user table.User) {
 	user.SubscribedPair = !user.SubscribedPair
-	err := b.storage.SaveUser(user)
+	err := b.saveUser(user)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("changeSubscribe save error: %v", err.Error()))
+	}
+
+	b.showSuccess(user)
+}
+
+func (b *Bot) changeEveningSubscribe(user table.User) {
+	user.SubscribedEvening = !user.SubscribedEvening
+	err := b.saveUser(user)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("changeSubscribe save error: %v", err.Error()))
+	}
+
+	b.showSuccess(user)
+}
+
+func (b *Bot) changeWeeklySubscribe(user table.User) {
+	user.SubscribedWeekly = !user.SubscribedWeekly
+	err := b.saveUser(user)
 	if err != nil {
 		b.logger.Warn(fmt.Sprintf("changeSubscribe save error: %v", err.Error()))
 	}
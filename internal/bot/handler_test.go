@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"bot/internal/bells"
+	"bot/internal/service"
+	"testing"
+	"time"
+)
+
+func TestNowText(t *testing.T) {
+	loadMsk(t)
+
+	bs := bells.Schedule{
+		{Start: 9 * 60, End: 10*60 + 30},
+		{Start: 10*60 + 40, End: 12*60 + 10},
+		{Start: 12*60 + 30, End: 14 * 60},
+		{Start: 14*60 + 20, End: 15*60 + 50},
+	}
+	day := service.WorkDay{
+		{{Subject: "Математика", Room: "101"}},
+		nil,
+		{{Subject: "Физика", Room: "202"}},
+		{{Subject: "История", Room: "303", Group: 2}},
+	}
+
+	tests := []struct {
+		name     string
+		day      service.WorkDay
+		subGroup int
+		at       string
+		want     string
+	}{
+		{
+			name: "no pairs",
+			day:  service.WorkDay{nil, nil},
+			at:   "10:00",
+			want: "Сегодня пар нет 🎉",
+		},
+		{
+			name: "before the first pair",
+			at:   "08:30",
+			want: "Сейчас 08:30\n\n" +
+				"Следующая пара №1 в 9:00 (через 30м): Математика\nКабинет: 101\n",
+		},
+		{
+			name: "current pair with a window after",
+			at:   "09:30",
+			want: "Сейчас 09:30\n\n" +
+				"Идет пара №1: Математика\nКабинет: 101\nДо конца: 1ч\n\n" +
+				"Следующая пара №3 в 12:30 (через 3ч): Физика\nКабинет: 202\n" +
+				"\nПосле пары окно 2ч",
+		},
+		{
+			name: "window",
+			at:   "11:00",
+			want: "Сейчас 11:00\n\n" +
+				"Сейчас окно 1ч 30м\n\n" +
+				"Следующая пара №3 в 12:30 (через 1ч 30м): Физика\nКабинет: 202\n",
+		},
+		{
+			name: "last pair",
+			at:   "13:00",
+			want: "Сейчас 13:00\n\n" +
+				"Идет пара №3: Физика\nКабинет: 202\nДо конца: 1ч\n\n" +
+				"Это последняя пара на сегодня.",
+		},
+		{
+			name: "day over",
+			at:   "15:00",
+			want: "Сейчас 15:00\n\nПары на сегодня закончились 🎉",
+		},
+		{
+			name:     "subgroup pair after a window",
+			subGroup: 2,
+			at:       "14:10",
+			want: "Сейчас 14:10\n\n" +
+				"Сейчас окно 10м\n\n" +
+				"Следующая пара №4 в 14:20 (через 10м): История\nКабинет: 303\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.day == nil {
+				tt.day = day
+			}
+			if tt.subGroup == 0 {
+				tt.subGroup = 1
+			}
+
+			at, err := time.ParseInLocation("2006-01-02 15:04", "2024-09-02 "+tt.at, mskLoc)
+			if err != nil {
+				t.Fatalf("ParseInLocation() error = %v", err)
+			}
+
+			if got := nowText(tt.day, tt.subGroup, bs, at, mskLoc); got != tt.want {
+				t.Errorf("nowText() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDayText(t *testing.T) {
+	day := service.WorkDay{
+		{{Subject: "Математика", Room: "101", Teacher: "Иванов"}},
+		{{Subject: "Физика", Room: "202", Teacher: "Петров", Group: 1}, {Subject: "Химия", Room: "303", Teacher: "Сидоров", Group: 2}},
+		nil,
+	}
+	notes := map[int][]string{1: {"📝 лабораторная"}}
+
+	tests := []struct {
+		name     string
+		subGroup int
+		both     bool
+		want     string
+	}{
+		{
+			name: "no subgroup",
+			want: "№1\nПредмет: Математика\nКабинет: 101\nПреподаватель: Иванов\n\n" +
+				"№2\nПара у другой группы\n\n" +
+				"№3\nНет\n\n",
+		},
+		{
+			name: "no subgroup, both shown",
+			both: true,
+			want: "№1\nПредмет: Математика\nКабинет: 101\nПреподаватель: Иванов\n\n" +
+				"№2\n1 подгруппа: Физика, каб. 202, Петров\n2 подгруппа: Химия, каб. 303, Сидоров\n📝 лабораторная\n\n" +
+				"№3\nНет\n\n",
+		},
+		{
+			name:     "subgroup",
+			subGroup: 2,
+			both:     true,
+			want: "№1\nПредмет: Математика\nКабинет: 101\nПреподаватель: Иванов\n\n" +
+				"№2\nПредмет: Химия\nКабинет: 303\nПреподаватель: Сидоров\n📝 лабораторная\n\n" +
+				"№3\nНет\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dayText(day, tt.subGroup, notes, tt.both); got != tt.want {
+				t.Errorf("dayText() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package bot
+
+import (
+	"bot/internal/constant"
+	"bot/internal/entity/table"
+	"bot/internal/service"
+	"errors"
+	"fmt"
+	api "gopkg.in/telegram-bot-api.v4"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const headmanHelp = `Команды старосты:
+/announce текст — объявление всей группе
+/announce 1 текст — объявление 1 подгруппе
+/pin 25.10-30.10 текст — закрепить объявление над расписанием на эти дни
+/pin завтра текст — закрепить на один день
+/pin — закрепленные объявления
+/members — кто из группы пользуется ботом
+/hw завтра 2 текст — домашнее задание`
+
+// isHeadman reports whether the user may manage the user's group: post
+// homework, announcements and notices.
+func isHeadman(user table.User) bool {
+	return user.Headman || user.Admin
+}
+
+// headmanUser returns the user who sent the command if the user is a headman,
+// otherwise the user is told the command is not allowed.
+func (b *Bot) headmanUser(msg *api.Message) (table.User, bool) {
+	user, ok := b.commandUser(msg)
+	if !ok {
+		return user, false
+	}
+
+	if !isHeadman(user) {
+		b.send(newMsgForUser("Эта команда доступна только старостам.", user.ChatID, &toScheduleKeyboard))
+		return user, false
+	}
+
+	return user, true
+}
+
+// handleHeadmanCommand lets admins grant the headman role of the user's group:
+// /headman <id или @ник> [off].
+func (b *Bot) handleHeadmanCommand(admin table.User, text string) {
+	args := strings.Fields(text)
+	if len(args) == 0 || len(args) > 2 || (len(args) == 2 && args[1] != "off") {
+		b.send(newMsgForUser("Пример: /headman @nickname или /headman 123456 off", admin.ChatID, nil))
+		return
+	}
+
+	user, err := b.userByRef(args[0])
+	if err != nil {
+		if errors.Is(err, constant.ErrUserNotFound) {
+			b.send(newMsgForUser("Пользователь не найден.", admin.ChatID, nil))
+			return
+		}
+		b.logger.Warn(fmt.Sprintf("handleHeadmanCommand get user error: %v", err.Error()))
+		return
+	}
+
+	user.Headman = len(args) == 1
+	if err := b.saveUser(user); err != nil {
+		b.logger.Warn(fmt.Sprintf("handleHeadmanCommand save error: %v", err.Error()))
+		return
+	}
+
+	if !user.Headman {
+		b.send(newMsgForUser(fmt.Sprintf("%s больше не староста.", user.Name), admin.ChatID, nil))
+		return
+	}
+
+	b.send(newMsgForUser(fmt.Sprintf("%s теперь староста группы %s.", user.Name, user.Group), admin.ChatID, nil))
+	b.send(newMsgForUser("Ты теперь староста группы "+user.Group+"!\n\n"+headmanHelp, user.ChatID, &toScheduleKeyboard))
+}
+
+// userByRef returns the user by the id or the @nickname.
+func (b *Bot) userByRef(ref string) (table.User, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return b.storage.GetUserByID(id)
+	}
+
+	return b.storage.GetUserByNickname(strings.TrimPrefix(ref, "@"))
+}
+
+// handleAnnounceCommand sends the headman's announcement to everyone in the
+// group or in one of its subgroups.
+func (b *Bot) handleAnnounceCommand(msg *api.Message) {
+	user, ok := b.headmanUser(msg)
+	if !ok {
+		return
+	}
+
+	text := strings.TrimSpace(msg.CommandArguments())
+
+	subGroup := 0
+	if split := strings.SplitN(text, " ", 2); len(split) == 2 && (split[0] == "1" || split[0] == "2") {
+		subGroup, _ = strconv.Atoi(split[0])
+		text = strings.TrimSpace(split[1])
+	}
+
+	if text == "" {
+		b.send(newMsgForUser(headmanHelp, user.ChatID, &toScheduleKeyboard))
+		return
+	}
+
+	members, err := b.storage.GetUsersByGroup(user.Group)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("handleAnnounceCommand get users error: %v", err.Error()))
+		return
+	}
+
+	var recipients []table.User
+	for _, m := range members {
+		if m.ID != user.ID && (subGroup == 0 || m.SubGroup == subGroup) {
+			recipients = append(recipients, m)
+		}
+	}
+
+	b.startBroadcast(user, fmt.Sprintf("📢 Объявление от старосты %s:\n\n%s", user.Name, text), recipients)
+}
+
+// handlePinCommand pins the notice above the schedule of the group for the
+// given days, without arguments the pinned notices are shown.
+func (b *Bot) handlePinCommand(msg *api.Message) {
+	user, ok := b.headmanUser(msg)
+	if !ok {
+		return
+	}
+
+	words := strings.Fields(msg.CommandArguments())
+	if len(words) == 0 {
+		b.showNotices(user)
+		return
+	}
+
+	first, last, text, ok := parseNotice(words, time.Now().In(mskLoc))
+	if !ok {
+		b.send(newMsgForUser(headmanHelp, user.ChatID, &toScheduleKeyboard))
+		return
+	}
+
+	notice := table.Notice{
+		Group:    user.Group,
+		FirstDay: first.Format(constant.DateLayout),
+		LastDay:  last.Format(constant.DateLayout),
+		Text:     text,
+		AuthorID: user.ID,
+	}
+
+	if err := b.storage.AddNotice(notice); err != nil {
+		b.logger.Warn(fmt.Sprintf("add notice error: %v", err.Error()))
+		return
+	}
+
+	b.showNotices(user)
+}
+
+// parseNotice parses the days and the text of the notice: the first word may
+// be a range of dates separated by a dash, otherwise the longest leading date
+// expression is taken as a single day.
+func parseNotice(words []string, now time.Time) (first, last time.Time, text string, ok bool) {
+	if len(words) < 2 {
+		return first, last, "", false
+	}
+
+	if split := strings.Split(words[0], "-"); len(split) == 2 {
+		from, okFrom := service.ParseDate(split[0], now)
+		to, okTo := service.ParseDate(split[1], now)
+		if okFrom && okTo && !to.Before(from) {
+			return from, to, strings.Join(words[1:], " "), true
+		}
+	}
+
+	for i := len(words) - 1; i > 0; i-- {
+		if day, ok := service.ParseDate(strings.Join(words[:i], " "), now); ok {
+			return day, day, strings.Join(words[i:], " "), true
+		}
+	}
+
+	return first, last, "", false
+}
+
+func (b *Bot) showNotices(user table.User) {
+	notices, err := b.storage.GetUpcomingNotices(user.Group, time.Now().In(mskLoc).Format(constant.DateLayout))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get upcoming notices error: %v", err.Error()))
+		return
+	}
+
+	if len(notices) == 0 {
+		b.send(newMsgForUser("Закрепленных объявлений нет.\n\n"+headmanHelp, user.ChatID, &toScheduleKeyboard))
+		return
+	}
+
+	var sb strings.Builder
+	var rows [][]api.InlineKeyboardButton
+
+	sb.WriteString("Закрепленные объявления, нажми чтобы открепить:\n\n")
+
+	for i, n := range notices {
+		if i == maxListed {
+			break
+		}
+
+		label := fmt.Sprintf("📌 %s–%s %s", shortDate(n.FirstDay), shortDate(n.LastDay), n.Text)
+		sb.WriteString(label + "\n")
+		rows = append(rows, api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("❌ "+truncate(label, 30), fmt.Sprintf("%s::%d", deleteNotice, n.ID)),
+		))
+	}
+
+	rows = append(rows, toScheduleKeyboard.InlineKeyboard...)
+	keyboard := api.NewInlineKeyboardMarkup(rows...)
+
+	b.send(newMsgForUser(sb.String(), user.ChatID, &keyboard))
+}
+
+func (b *Bot) deleteNotice(user table.User, arg string) {
+	if !isHeadman(user) {
+		return
+	}
+
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("delete notice id error: %v", err.Error()))
+		return
+	}
+
+	if id <= 0 {
+		b.logger.Warn(fmt.Sprintf("delete notice id error: invalid id %d", id))
+		return
+	}
+
+	if err := b.storage.DeleteNotice(user.Group, id); err != nil {
+		b.logger.Warn(fmt.Sprintf("delete notice error: %v", err.Error()))
+		return
+	}
+
+	b.showNotices(user)
+}
+
+// noticesText renders the notices of the group pinned for the date, an empty
+// string is returned if there are none.
+func (b *Bot) noticesText(group string, date time.Time) string {
+	notices, err := b.storage.GetNotices(group, date.Format(constant.DateLayout))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get notices error: %v", err.Error()))
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, n := range notices {
+		sb.WriteString(fmt.Sprintf("📌 %s\n", n.Text))
+	}
+
+	if sb.Len() != 0 {
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// handleMembersCommand shows the headman who in the group uses the bot.
+func (b *Bot) handleMembersCommand(msg *api.Message) {
+	user, ok := b.headmanUser(msg)
+	if !ok {
+		return
+	}
+
+	members, err := b.storage.GetUsersByGroup(user.Group)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("handleMembersCommand get users error: %v", err.Error()))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Группа %s, пользуются ботом: %d\n\n", user.Group, len(members)))
+
+	for _, m := range members {
+		sb.WriteString("• " + m.Name)
+		if m.Nickname != "" {
+			sb.WriteString(" @" + m.Nickname)
+		}
+		if m.SubGroup != 0 {
+			sb.WriteString(fmt.Sprintf(", %d подгруппа", m.SubGroup))
+		}
+		if m.Headman {
+			sb.WriteString(", староста")
+		}
+		sb.WriteString("\n")
+	}
+
+	b.send(newMsgForUser(sb.String(), user.ChatID, &toScheduleKeyboard))
+}
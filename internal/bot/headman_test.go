@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNotice(t *testing.T) {
+	// Wednesday
+	now := time.Date(2023, time.October, 18, 14, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2023, time.October, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		text      string
+		wantFirst time.Time
+		wantLast  time.Time
+		wantText  string
+		wantOk    bool
+	}{
+		{
+			name:      "single day",
+			text:      "в пятницу экскурсия в музей",
+			wantFirst: day(20),
+			wantLast:  day(20),
+			wantText:  "экскурсия в музей",
+			wantOk:    true,
+		},
+		{
+			name:      "single date",
+			text:      "25.10 консультация",
+			wantFirst: day(25),
+			wantLast:  day(25),
+			wantText:  "консультация",
+			wantOk:    true,
+		},
+		{
+			name:      "range",
+			text:      "23.10-27.10 практика на заводе",
+			wantFirst: day(23),
+			wantLast:  day(27),
+			wantText:  "практика на заводе",
+			wantOk:    true,
+		},
+		{
+			name: "reversed range",
+			text: "27.10-23.10 практика",
+		},
+		{
+			name: "missing text",
+			text: "23.10-27.10",
+		},
+		{
+			name: "no date",
+			text: "практика на заводе",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, last, text, ok := parseNotice(strings.Fields(tt.text), now)
+			if ok != tt.wantOk {
+				t.Fatalf("parseNotice() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+
+			if !first.Equal(tt.wantFirst) || !last.Equal(tt.wantLast) || text != tt.wantText {
+				t.Errorf("parseNotice() = %v, %v, %q, want %v, %v, %q",
+					first, last, text, tt.wantFirst, tt.wantLast, tt.wantText)
+			}
+		})
+	}
+}
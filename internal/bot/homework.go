@@ -0,0 +1,189 @@
+package bot
+
+import (
+	"bot/internal/constant"
+	"bot/internal/entity/table"
+	"bot/internal/service"
+	"fmt"
+	api "gopkg.in/telegram-bot-api.v4"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const homeworkHelp = `Домашнее задание:
+/hw — задания группы
+/hw завтра 2 решить задачи 1-5 — задание к предмету 2 пары (только для старост)`
+
+func (b *Bot) handleHomeworkCommand(msg *api.Message) {
+	user, ok := b.commandUser(msg)
+	if !ok {
+		return
+	}
+
+	words := strings.Fields(msg.CommandArguments())
+	if len(words) == 0 {
+		b.showHomework(user)
+		return
+	}
+
+	if !isHeadman(user) {
+		b.send(newMsgForUser("Задания могут добавлять только старосты.", user.ChatID, &toScheduleKeyboard))
+		return
+	}
+
+	now := time.Now().In(mskLoc)
+
+	for i := 1; i < len(words)-1; i++ {
+		pair, err := strconv.Atoi(words[i])
+		if err != nil || pair < 1 || pair > b.bells.MaxPairs() {
+			continue
+		}
+
+		date, ok := service.ParseDate(strings.Join(words[:i], " "), now)
+		if !ok {
+			break
+		}
+
+		subject, ok := b.pairSubject(user, date, pair-1)
+		if !ok {
+			b.send(newMsgForUser(
+				fmt.Sprintf("В %s нет пары №%d.", date.Format("02.01"), pair),
+				user.ChatID, &toScheduleKeyboard,
+			))
+			return
+		}
+
+		hw := table.Homework{
+			Group:    user.Group,
+			Subject:  subject,
+			Due:      date.Format(constant.DateLayout),
+			Text:     strings.Join(words[i+1:], " "),
+			AuthorID: user.ID,
+		}
+
+		if err := b.storage.AddHomework(hw); err != nil {
+			b.logger.Warn(fmt.Sprintf("add homework error: %v", err.Error()))
+			return
+		}
+
+		b.send(newMsgForUser(
+			fmt.Sprintf("Задание по предмету %s на %s добавлено 📚", subject, date.Format("02.01")),
+			user.ChatID, &toScheduleKeyboard,
+		))
+		return
+	}
+
+	b.send(newMsgForUser(homeworkHelp, user.ChatID, &toScheduleKeyboard))
+}
+
+// pairSubject returns the subject of the pair of the user's group on the date.
+func (b *Bot) pairSubject(user table.User, date time.Time, offset int) (string, bool) {
+	cd := b.calendar.Day(user.Group, date)
+	if !cd.Study {
+		return "", false
+	}
+
+	day, err := b.schedule.GetDayByGroup(user.Group, weekdayToInt(cd.Weekday))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get day error: %v", err.Error()))
+		return "", false
+	}
+
+	if offset >= len(day) {
+		return "", false
+	}
+
+	p, err := findGroup(day[offset], user.SubGroup)
+	if err != nil {
+		return "", false
+	}
+
+	return p.Subject, true
+}
+
+// showHomework lists the outstanding homework of the user's group, headmen get
+// the buttons to delete it.
+func (b *Bot) showHomework(user table.User) {
+	homework, err := b.storage.GetUpcomingHomework(user.Group, time.Now().In(mskLoc).Format(constant.DateLayout))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get upcoming homework error: %v", err.Error()))
+		return
+	}
+
+	if len(homework) == 0 {
+		b.send(newMsgForUser("Заданий нет 🎉\n\n"+homeworkHelp, user.ChatID, &toScheduleKeyboard))
+		return
+	}
+
+	var sb strings.Builder
+	var rows [][]api.InlineKeyboardButton
+
+	sb.WriteString(fmt.Sprintf("Задания группы %s:\n\n", user.Group))
+
+	for i, hw := range homework {
+		if i == maxListed {
+			break
+		}
+
+		label := fmt.Sprintf("📚 %s %s: %s", shortDate(hw.Due), hw.Subject, hw.Text)
+		sb.WriteString(label + "\n")
+
+		if isHeadman(user) {
+			rows = append(rows, api.NewInlineKeyboardRow(
+				api.NewInlineKeyboardButtonData("❌ "+truncate(label, 30), fmt.Sprintf("%s::%d", deleteHomework, hw.ID)),
+			))
+		}
+	}
+
+	rows = append(rows, toScheduleKeyboard.InlineKeyboard...)
+	keyboard := api.NewInlineKeyboardMarkup(rows...)
+
+	b.send(newMsgForUser(sb.String(), user.ChatID, &keyboard))
+}
+
+func (b *Bot) deleteHomework(user table.User, arg string) {
+	if !isHeadman(user) {
+		return
+	}
+
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("delete homework id error: %v", err.Error()))
+		return
+	}
+
+	if id <= 0 {
+		b.logger.Warn(fmt.Sprintf("delete homework id error: invalid id %d", id))
+		return
+	}
+
+	if err := b.storage.DeleteHomework(user.Group, id); err != nil {
+		b.logger.Warn(fmt.Sprintf("delete homework error: %v", err.Error()))
+		return
+	}
+
+	b.showHomework(user)
+}
+
+// homeworkDigest renders the homework of the group due the date, an empty
+// string is returned if there is none.
+func (b *Bot) homeworkDigest(group string, date time.Time) string {
+	homework, err := b.storage.GetHomework(group, date.Format(constant.DateLayout))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("homeworkDigest error: GetHomework error: %v", err.Error()))
+		return ""
+	}
+
+	if len(homework) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Домашнее задание на завтра, %s:\n\n", date.Format("02.01")))
+	for _, hw := range homework {
+		sb.WriteString(fmt.Sprintf("📚 %s: %s\n", hw.Subject, hw.Text))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"bot/internal/service"
+	"fmt"
+	api "gopkg.in/telegram-bot-api.v4"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxInlineResults is the maximum number of results Telegram accepts.
+const maxInlineResults = 50
+
+// handleInlineQuery answers inline queries like "04 74-20 2 завтра": a group,
+// an optional subgroup and an optional date expression. An empty query
+// returns the schedule of the caller.
+func (b *Bot) handleInlineQuery(query *api.InlineQuery) {
+	now := time.Now().In(mskLoc)
+	text := strings.TrimSpace(query.Query)
+
+	answer := api.InlineConfig{
+		InlineQueryID: query.ID,
+		CacheTime:     60,
+	}
+
+	if text == "" {
+		answer.IsPersonal = true
+		answer.Results = b.ownInlineResults(query.From.ID, now)
+		if len(answer.Results) == 0 {
+			answer.SwitchPMText = "Выбрать группу"
+			answer.SwitchPMParameter = "inline"
+		}
+	} else {
+		answer.Results = b.groupInlineResults(text, now)
+	}
+
+	if _, err := b.AnswerInlineQuery(answer); err != nil {
+		b.logger.Warn(fmt.Sprintf("answer inline query error: %v", err.Error()))
+	}
+}
+
+// ownInlineResults returns the nearest study day and the one after it for the
+// group of the registered user.
+func (b *Bot) ownInlineResults(userID int, now time.Time) []interface{} {
+	user, err := b.storage.GetUserByID(userID)
+	if err != nil || user.Group == "" {
+		return nil
+	}
+
+	first := b.nextStudyDay(now, 0)
+	second := b.nextStudyDay(first, 1)
+
+	var results []interface{}
+	for _, date := range []time.Time{first, second} {
+		if r, ok := b.inlineResult(len(results), user.Group, user.SubGroup, date); ok {
+			results = append(results, r)
+		}
+	}
+
+	return results
+}
+
+// groupInlineResults parses the query into a group, a subgroup and a date. If
+// the query doesn't start with a known group, groups starting with the query
+// are suggested.
+func (b *Bot) groupInlineResults(text string, now time.Time) []interface{} {
+	words := strings.Fields(text)
+
+	for i := len(words); i > 0; i-- {
+		group := strings.Join(words[:i], " ")
+		if !b.schedule.VerifyGroup(group) {
+			continue
+		}
+
+		rest := words[i:]
+
+		subGroup := 0
+		if len(rest) > 0 {
+			if sg, err := strconv.Atoi(rest[0]); err == nil && (sg == 1 || sg == 2) {
+				subGroup = sg
+				rest = rest[1:]
+			}
+		}
+
+		date := b.nextStudyDay(now, 0)
+		if len(rest) > 0 {
+			var ok bool
+			if date, ok = service.ParseDate(strings.Join(rest, " "), now); !ok {
+				return nil
+			}
+		}
+
+		if r, ok := b.inlineResult(0, group, subGroup, date); ok {
+			return []interface{}{r}
+		}
+
+		return nil
+	}
+
+	var results []interface{}
+	date := b.nextStudyDay(now, 0)
+	for _, group := range b.schedule.GetDayGroupNames() {
+		if len(results) == maxInlineResults {
+			break
+		}
+
+		if !strings.HasPrefix(group, text) {
+			continue
+		}
+
+		if r, ok := b.inlineResult(len(results), group, 0, date); ok {
+			results = append(results, r)
+		}
+	}
+
+	return results
+}
+
+// inlineResult renders the day of the group as an inline article.
+func (b *Bot) inlineResult(id int, group string, subGroup int, date time.Time) (api.InlineQueryResultArticle, bool) {
+	body, err := b.dateText(group, subGroup, date, true)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("inline result error: %v", err.Error()))
+		return api.InlineQueryResultArticle{}, false
+	}
+
+	title := "Группа " + group
+	if subGroup != 0 {
+		title += fmt.Sprintf(", %d подгруппа", subGroup)
+	}
+
+	r := api.NewInlineQueryResultArticle(strconv.Itoa(id), title, title+"\n"+body)
+	r.Description = fmt.Sprintf("%s %s", toDay(int(date.Weekday()+6)%7), date.Format("02.01"))
+
+	return r, true
+}
+
+// nextStudyDay returns the midnight of the first study day that is at least
+// days after t.
+func (b *Bot) nextStudyDay(t time.Time, days int) time.Time {
+	date := time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, 0, t.Location())
+	// a long vacation must not hang the query
+	for i := 0; i < 366 && !b.calendar.Open(date); i++ {
+		date = date.AddDate(0, 0, 1)
+	}
+
+	return date
+}
@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"bot/internal/constant"
+	"bot/internal/entity/table"
+	"bot/internal/storage"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	// catchUpGrace is how long after its time a daily notification missed,
+	// e.g. during a restart, is still sent.
+	catchUpGrace = 2 * time.Hour
+	// ledgerDays is the number of days the deliveries are kept.
+	ledgerDays = 30
+)
+
+// ledger remembers the scheduled notifications sent, so each one is sent at
+// most once even if the bot restarts. The claimed keys are cached, the
+// senders check them every minute.
+type ledger struct {
+	storage storage.DeliveryRepository
+	logger  *zap.Logger
+
+	mu      sync.Mutex
+	claimed map[table.Delivery]struct{}
+}
+
+func newLedger(storage storage.DeliveryRepository, logger *zap.Logger) *ledger {
+	return &ledger{
+		storage: storage,
+		logger:  logger,
+		claimed: make(map[table.Delivery]struct{}),
+	}
+}
+
+// delivery returns the ledger key of the notification to the user.
+func delivery(user table.User, kind string, ref int, date time.Time, pair int) table.Delivery {
+	return table.Delivery{
+		UserID: user.ID,
+		ChatID: user.ChatID,
+		Kind:   kind,
+		Ref:    ref,
+		Date:   date.Format(constant.DateLayout),
+		Pair:   pair,
+	}
+}
+
+// claim reports whether the notification must be sent now, it is recorded as
+// sent if so. If the ledger is not available the notification is not sent, so
+// it is never sent twice.
+func (l *ledger) claim(d table.Delivery, now time.Time) bool {
+	key := d
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.claimed[key]; ok {
+		return false
+	}
+
+	d.SentAt = now
+	ok, err := l.storage.ClaimDelivery(&d)
+	if err != nil {
+		l.logger.Warn(fmt.Sprintf("claim delivery error: %v", err.Error()))
+		return false
+	}
+
+	l.claimed[key] = struct{}{}
+
+	return ok
+}
+
+// prune forgets the deliveries older than ledgerDays, the cache keeps only
+// today and later.
+func (l *ledger) prune(now time.Time) {
+	today := now.Format(constant.DateLayout)
+
+	l.mu.Lock()
+	for key := range l.claimed {
+		if key.Date < today {
+			delete(l.claimed, key)
+		}
+	}
+	l.mu.Unlock()
+
+	n, err := l.storage.DeleteDeliveriesBefore(now.AddDate(0, 0, -ledgerDays).Format(constant.DateLayout))
+	if err != nil {
+		l.logger.Warn(fmt.Sprintf("prune deliveries error: %v", err.Error()))
+		return
+	}
+
+	l.logger.Info(fmt.Sprintf("pruned %d deliveries", n))
+}
+
+// due reports whether the notification at the time at must be sent at the
+// moment now, it is late by less than catchUpGrace.
+func due(at, now time.Time) bool {
+	return !now.Before(at) && now.Sub(at) < catchUpGrace
+}
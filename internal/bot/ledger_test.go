@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"bot/internal/entity/table"
+	"bot/internal/storage"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestLedger_Claim(t *testing.T) {
+	s := storage.NewMemory()
+
+	now := time.Date(2024, time.September, 2, 8, 0, 0, 0, time.UTC)
+	user := table.User{ID: 1, ChatID: 1}
+
+	l := newLedger(s, zap.NewNop())
+	if !l.claim(delivery(user, table.DeliveryDaily, 0, now, 0), now) {
+		t.Fatalf("first claim() = false")
+	}
+	if l.claim(delivery(user, table.DeliveryDaily, 0, now, 0), now) {
+		t.Errorf("second claim() = true")
+	}
+	if !l.claim(delivery(user, table.DeliveryPair, 0, now, 1), now) {
+		t.Errorf("claim() of another kind = false")
+	}
+
+	// the bot restarted
+	l = newLedger(s, zap.NewNop())
+	if l.claim(delivery(user, table.DeliveryDaily, 0, now, 0), now) {
+		t.Errorf("claim() after restart = true")
+	}
+
+	ds, err := s.GetDeliveries(now.Format("2006-01-02"), user.ID)
+	if err != nil || len(ds) != 2 {
+		t.Errorf("GetDeliveries() = %v, %v, want 2 deliveries", ds, err)
+	}
+}
+
+func TestDue(t *testing.T) {
+	at := time.Date(2024, time.September, 2, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{at.Add(-time.Minute), false},
+		{at, true},
+		{at.Add(15 * time.Minute), true},
+		{at.Add(catchUpGrace), false},
+	}
+
+	for _, tt := range tests {
+		if got := due(at, tt.now); got != tt.want {
+			t.Errorf("due(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"bot/internal/entity/table"
+	"fmt"
+	api "gopkg.in/telegram-bot-api.v4"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Group of mute presets.
+const (
+	muteHour = "hour"
+	muteDay  = "day"
+	muteWeek = "week"
+	muteDate = "date"
+	quietOff = "off"
+)
+
+// muteDays is the number of days offered when muting until a chosen date.
+const muteDays = 14
+
+// isMuted reports whether notifications to the user are off at the moment now:
+// the user muted them until a later time or now is within the quiet hours.
+func isMuted(user table.User, now time.Time) bool {
+	return now.Before(user.SilenceUntil) || inQuietHours(user, now)
+}
+
+// inQuietHours reports whether now is within the user's daily quiet hours in
+// the user's time zone. The window may span midnight, equal bounds mean there
+// are no quiet hours.
+func inQuietHours(user table.User, now time.Time) bool {
+	from, to, hour := user.QuietFrom, user.QuietTo, now.In(userLoc(user)).Hour()
+	if from == to {
+		return false
+	}
+
+	if from < to {
+		return hour >= from && hour < to
+	}
+
+	return hour >= from || hour < to
+}
+
+// muteStatus describes the user's mute state, an empty string means
+// notifications are on.
+func muteStatus(user table.User, now time.Time) string {
+	var lines []string
+
+	if now.Before(user.SilenceUntil) {
+		lines = append(lines, fmt.Sprintf("🔕 Уведомления выключены до %s", user.SilenceUntil.In(userLoc(user)).Format("02.01 15:04")))
+	}
+
+	if user.QuietFrom != user.QuietTo {
+		lines = append(lines, fmt.Sprintf("🌙 Тихие часы: %d:00–%d:00", user.QuietFrom, user.QuietTo))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (b *Bot) showMute(user table.User) {
+	text := "На сколько выключить уведомления о расписании и парах?"
+	if status := muteStatus(user, time.Now().In(mskLoc)); status != "" {
+		text = status + "\n\n" + text
+	}
+
+	b.send(newMsgForUser(text, user.ChatID, &muteKeyboard))
+}
+
+// handleMute mutes the user according to the preset, or shows the dates to
+// choose from. The days end at midnight in the user's time zone.
+func (b *Bot) handleMute(user table.User, preset string) {
+	loc := userLoc(user)
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	switch preset {
+	case muteHour:
+		b.mute(user, now.Add(time.Hour))
+	case muteDay:
+		b.mute(user, today.AddDate(0, 0, 1))
+	case muteWeek:
+		b.mute(user, weekStart(now).AddDate(0, 0, 7))
+	case muteDate:
+		b.send(newMsgForUser("До какого дня включительно выключить уведомления?", user.ChatID, newMuteDateKeyboard(today)))
+	default:
+		date, err := time.ParseInLocation("2006-01-02", preset, loc)
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("handleMute wrong preset: %v", preset))
+			return
+		}
+
+		b.mute(user, date.AddDate(0, 0, 1))
+	}
+}
+
+// mute turns the user's notifications off until the given time.
+func (b *Bot) mute(user table.User, until time.Time) {
+	user.SilenceUntil = until
+
+	b.logger.Info(fmt.Sprintf("user %d muted until %v", user.ID, until))
+
+	err := b.saveUser(user)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("mute error: SaveUser error: %v", err.Error()))
+	}
+
+	b.send(newMsgForUser(
+		fmt.Sprintf("Уведомления выключены до %s.", until.In(userLoc(user)).Format("02.01 15:04")),
+		user.ChatID, &unmuteKeyboard,
+	))
+}
+
+func (b *Bot) unmute(user table.User) {
+	user.SilenceUntil = time.Time{}
+
+	err := b.saveUser(user)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("unmute error: SaveUser error: %v", err.Error()))
+	}
+
+	b.send(newMsgForUser("Уведомления снова включены!", user.ChatID, &toScheduleKeyboard))
+}
+
+// changeQuietHours sets the daily quiet hours given as "from-to", or turns
+// them off.
+func (b *Bot) changeQuietHours(user table.User, value string) {
+	if value == quietOff {
+		user.QuietFrom, user.QuietTo = 0, 0
+	} else {
+		split := strings.Split(value, "-")
+		if len(split) != 2 {
+			b.logger.Warn(fmt.Sprintf("changeQuietHours wrong value: %v", value))
+			return
+		}
+
+		from, errFrom := strconv.Atoi(split[0])
+		to, errTo := strconv.Atoi(split[1])
+		if errFrom != nil || errTo != nil || from < 0 || from > 23 || to < 0 || to > 23 {
+			b.logger.Warn(fmt.Sprintf("changeQuietHours wrong value: %v", value))
+			return
+		}
+
+		user.QuietFrom, user.QuietTo = from, to
+	}
+
+	err := b.saveUser(user)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("changeQuietHours save error: %v", err.Error()))
+	}
+
+	if user.QuietFrom == user.QuietTo {
+		b.send(newMsgForUser("Тихие часы выключены.", user.ChatID, &toScheduleKeyboard))
+		return
+	}
+
+	b.send(newMsgForUser(
+		fmt.Sprintf("Тихие часы: %d:00–%d:00. В это время я ничего не присылаю.", user.QuietFrom, user.QuietTo),
+		user.ChatID, &toScheduleKeyboard,
+	))
+}
+
+// newMuteDateKeyboard returns the keyboard with the days after today.
+func newMuteDateKeyboard(today time.Time) *api.InlineKeyboardMarkup {
+	var rows [][]api.InlineKeyboardButton
+	var row []api.InlineKeyboardButton
+
+	for i := 1; i <= muteDays; i++ {
+		date := today.AddDate(0, 0, i)
+		row = append(row, api.NewInlineKeyboardButtonData(date.Format("02.01"), mute+"::"+date.Format("2006-01-02")))
+
+		if len(row) == 7 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+
+	rows = append(rows, api.NewInlineKeyboardRow(api.NewInlineKeyboardButtonData("Назад", mute)))
+
+	keyboard := api.NewInlineKeyboardMarkup(rows...)
+	return &keyboard
+}
@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"bot/internal/entity/table"
+	"testing"
+	"time"
+)
+
+func TestInQuietHours(t *testing.T) {
+	loadMsk(t)
+
+	tests := []struct {
+		name     string
+		from, to int
+		hour     int
+		want     bool
+	}{
+		{name: "no quiet hours", from: 0, to: 0, hour: 0, want: false},
+		{name: "equal bounds", from: 22, to: 22, hour: 22, want: false},
+		{name: "inside", from: 13, to: 15, hour: 14, want: true},
+		{name: "start included", from: 13, to: 15, hour: 13, want: true},
+		{name: "end excluded", from: 13, to: 15, hour: 15, want: false},
+		{name: "over midnight late", from: 22, to: 7, hour: 23, want: true},
+		{name: "over midnight early", from: 22, to: 7, hour: 6, want: true},
+		{name: "over midnight end", from: 22, to: 7, hour: 7, want: false},
+		{name: "over midnight outside", from: 22, to: 7, hour: 12, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := table.User{QuietFrom: tt.from, QuietTo: tt.to}
+			now := time.Date(2024, time.September, 2, tt.hour, 30, 0, 0, mskLoc)
+
+			if got := inQuietHours(user, now); got != tt.want {
+				t.Errorf("inQuietHours() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package bot
+
+import (
+	"bot/internal/bells"
+	"bot/internal/constant"
+	"bot/internal/entity/table"
+	"bot/internal/service"
+	"fmt"
+	api "gopkg.in/telegram-bot-api.v4"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxListed is the maximum number of notes and events listed by /notes.
+const maxListed = 20
+
+const notesHelp = `Заметки и события:
+/note завтра 3 принести лабораторную — заметка к 3 паре
+/event 25.10 16:00 Консультация @ 305 — личное событие, место после @
+/notes — список заметок и событий`
+
+// pairNotes returns the lines shown under the pairs of the date by the pair
+// index: the homework of the viewed group and, for the primary group, the
+// user's notes.
+func (b *Bot) pairNotes(user table.User, v view, date time.Time) map[int][]string {
+	var notes map[int][]string
+	if v.followID == 0 {
+		userNotes, err := b.storage.GetNotes(user.ID, date.Format(constant.DateLayout))
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("get notes error: %v", err.Error()))
+		}
+		notes = service.NotesByPair(userNotes)
+	}
+
+	homework, err := b.storage.GetHomework(v.group, date.Format(constant.DateLayout))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get homework error: %v", err.Error()))
+		return notes
+	}
+
+	if len(homework) == 0 {
+		return notes
+	}
+
+	cd := b.calendar.Day(v.group, date)
+	if !cd.Study {
+		return notes
+	}
+
+	day, err := b.schedule.GetDayByGroup(v.group, weekdayToInt(cd.Weekday))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get day error: %v", err.Error()))
+		return notes
+	}
+
+	return service.HomeworkByPair(notes, day, homework, v.subGroup)
+}
+
+// eventsText renders the user's events of the date, an empty string is
+// returned if there are none.
+func (b *Bot) eventsText(user table.User, date time.Time) string {
+	events, err := b.storage.GetEvents(user.ID, date.Format(constant.DateLayout))
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get events error: %v", err.Error()))
+		return ""
+	}
+
+	if len(events) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Мои события:\n")
+	for _, e := range events {
+		sb.WriteString(fmt.Sprintf("🗓 %s\n", eventText(e)))
+	}
+
+	return sb.String()
+}
+
+func eventText(e table.Event) string {
+	if e.Place == "" {
+		return fmt.Sprintf("%s %s", bells.FormatMinute(e.Start), e.Title)
+	}
+
+	return fmt.Sprintf("%s %s (%s)", bells.FormatMinute(e.Start), e.Title, e.Place)
+}
+
+func (b *Bot) handleNoteCommand(msg *api.Message) {
+	user, ok := b.commandUser(msg)
+	if !ok {
+		return
+	}
+
+	words := strings.Fields(msg.CommandArguments())
+	now := time.Now().In(mskLoc)
+
+	for i := 1; i < len(words)-1; i++ {
+		pair, err := strconv.Atoi(words[i])
+		if err != nil || pair < 1 || pair > b.bells.MaxPairs() {
+			continue
+		}
+
+		date, ok := service.ParseDate(strings.Join(words[:i], " "), now)
+		if !ok {
+			break
+		}
+
+		note := table.Note{
+			UserID: user.ID,
+			Date:   date.Format(constant.DateLayout),
+			Pair:   pair,
+			Text:   strings.Join(words[i+1:], " "),
+		}
+
+		if err := b.storage.AddNote(note); err != nil {
+			b.logger.Warn(fmt.Sprintf("add note error: %v", err.Error()))
+			return
+		}
+
+		b.send(newMsgForUser(
+			fmt.Sprintf("Заметка к паре №%d на %s сохранена 📝", pair, date.Format("02.01")),
+			user.ChatID, &toScheduleKeyboard,
+		))
+		return
+	}
+
+	b.send(newMsgForUser(notesHelp, user.ChatID, &toScheduleKeyboard))
+}
+
+func (b *Bot) handleEventCommand(msg *api.Message) {
+	user, ok := b.commandUser(msg)
+	if !ok {
+		return
+	}
+
+	words := strings.Fields(msg.CommandArguments())
+	// the events are in the user's time zone
+	now := time.Now().In(userLoc(user))
+
+	for i := 1; i < len(words)-1; i++ {
+		at, err := time.Parse("15:04", words[i])
+		if err != nil {
+			continue
+		}
+
+		date, ok := service.ParseDate(strings.Join(words[:i], " "), now)
+		if !ok {
+			break
+		}
+
+		event := table.Event{
+			UserID: user.ID,
+			Date:   date.Format(constant.DateLayout),
+			Start:  at.Hour()*60 + at.Minute(),
+			Title:  strings.Join(words[i+1:], " "),
+		}
+
+		if split := strings.SplitN(event.Title, "@", 2); len(split) == 2 {
+			event.Title = strings.TrimSpace(split[0])
+			event.Place = strings.TrimSpace(split[1])
+		}
+
+		if err := b.storage.AddEvent(event); err != nil {
+			b.logger.Warn(fmt.Sprintf("add event error: %v", err.Error()))
+			return
+		}
+
+		b.send(newMsgForUser(
+			fmt.Sprintf("Событие на %s сохранено: %s", date.Format("02.01"), eventText(event)),
+			user.ChatID, &toScheduleKeyboard,
+		))
+		return
+	}
+
+	b.send(newMsgForUser(notesHelp, user.ChatID, &toScheduleKeyboard))
+}
+
+// handleNotesCommand lists the upcoming notes and events with buttons to
+// delete them.
+func (b *Bot) handleNotesCommand(msg *api.Message) {
+	if user, ok := b.commandUser(msg); ok {
+		b.showNotes(user)
+	}
+}
+
+func (b *Bot) showNotes(user table.User) {
+	from := time.Now().In(mskLoc).Format(constant.DateLayout)
+
+	notes, err := b.storage.GetUpcomingNotes(user.ID, from)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get upcoming notes error: %v", err.Error()))
+		return
+	}
+
+	events, err := b.storage.GetUpcomingEvents(user.ID, from)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("get upcoming events error: %v", err.Error()))
+		return
+	}
+
+	if len(notes) == 0 && len(events) == 0 {
+		b.send(newMsgForUser("Заметок и событий нет.\n\n"+notesHelp, user.ChatID, &toScheduleKeyboard))
+		return
+	}
+
+	var sb strings.Builder
+	var rows [][]api.InlineKeyboardButton
+
+	sb.WriteString("Нажми на заметку или событие, чтобы удалить.\n\n")
+
+	for i, n := range notes {
+		if i == maxListed {
+			break
+		}
+
+		label := fmt.Sprintf("📝 %s №%d %s", shortDate(n.Date), n.Pair, n.Text)
+		sb.WriteString(label + "\n")
+		rows = append(rows, api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("❌ "+truncate(label, 30), fmt.Sprintf("%s::%d", deleteNote, n.ID)),
+		))
+	}
+
+	for i, e := range events {
+		if i == maxListed {
+			break
+		}
+
+		label := fmt.Sprintf("🗓 %s %s", shortDate(e.Date), eventText(e))
+		sb.WriteString(label + "\n")
+		rows = append(rows, api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("❌ "+truncate(label, 30), fmt.Sprintf("%s::%d", deleteEvent, e.ID)),
+		))
+	}
+
+	rows = append(rows, toScheduleKeyboard.InlineKeyboard...)
+	keyboard := api.NewInlineKeyboardMarkup(rows...)
+
+	b.send(newMsgForUser(sb.String(), user.ChatID, &keyboard))
+}
+
+func (b *Bot) deleteNoteOrEvent(user table.User, kind string, arg string) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("delete note id error: %v", err.Error()))
+		return
+	}
+
+	if kind == deleteNote {
+		err = b.storage.DeleteNote(user.ID, id)
+	} else {
+		err = b.storage.DeleteEvent(user.ID, id)
+	}
+
+	if err != nil {
+		b.logger.Warn(fmt.Sprintf("delete note error: %v", err.Error()))
+		return
+	}
+
+	b.showNotes(user)
+}
+
+// remindEvents reminds the users about their personal events the users' lead
+// time before the start. The events are in the users' time zones, so the ones
+// of the neighbouring dates are checked too.
+func (b *Bot) remindEvents(now time.Time) {
+	var events []table.Event
+	for days := -1; days <= 1; days++ {
+		es, err := b.storage.GetEventsByDate(now.AddDate(0, 0, days).Format(constant.DateLayout))
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("remindEvents error: GetEventsByDate error: %v", err.Error()))
+			return
+		}
+		events = append(events, es...)
+	}
+
+	for _, e := range events {
+		user, err := b.storage.GetUserByID(e.UserID)
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("remindEvents error: GetUserByID error: %v", err.Error()))
+			continue
+		}
+
+		local := now.In(userLoc(user))
+		if e.Date != local.Format(constant.DateLayout) {
+			continue
+		}
+
+		// the reminder is due until the event starts
+		minute := local.Hour()*60 + local.Minute()
+		if minute < e.Start-user.RemindBefore || minute >= e.Start || user.Blocked || isMuted(user, now) {
+			continue
+		}
+
+		if !b.ledger.claim(delivery(user, table.DeliveryEvent, e.ID, local, 0), now) {
+			continue
+		}
+
+		b.send(newMsgForUser("Напоминание о событии:\n🗓 "+eventText(e), user.ChatID, &toScheduleKeyboard))
+	}
+}
+
+// shortDate formats the date stored in the constant.DateLayout as DD.MM.
+func shortDate(date string) string {
+	t, err := time.Parse(constant.DateLayout, date)
+	if err != nil {
+		return date
+	}
+
+	return t.Format("02.01")
+}
+
+// truncate cuts the text to n runes.
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,178 @@
+package bot
+
+import (
+	"bot/internal/entity/table"
+	"sort"
+	"sync"
+)
+
+// registry keeps the users to notify in memory, indexed by the group for the
+// daily schedule and for the pairs. It is updated on every change of a user,
+// so the senders don't query the storage every minute.
+type registry struct {
+	mu    sync.RWMutex
+	users map[int]table.User
+	daily map[string]map[int]struct{}
+	pair  map[string]map[int]struct{}
+	// blocked are the users who blocked the bot, a message from one of them
+	// reactivates the user.
+	blocked map[int]struct{}
+}
+
+func newRegistry() *registry {
+	return &registry{
+		users:   make(map[int]table.User),
+		daily:   make(map[string]map[int]struct{}),
+		pair:    make(map[string]map[int]struct{}),
+		blocked: make(map[int]struct{}),
+	}
+}
+
+// load replaces the registry contents with the users.
+func (r *registry) load(users []table.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users = make(map[int]table.User)
+	r.daily = make(map[string]map[int]struct{})
+	r.pair = make(map[string]map[int]struct{})
+	r.blocked = make(map[int]struct{})
+
+	for _, u := range users {
+		r.add(u)
+	}
+}
+
+// set updates the user, the user is dropped if there is nothing to notify the
+// user about.
+func (r *registry) set(u table.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.unset(u.ID)
+	r.add(u)
+}
+
+func (r *registry) remove(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.unset(id)
+}
+
+func (r *registry) add(u table.User) {
+	if u.Blocked {
+		r.blocked[u.ID] = struct{}{}
+		return
+	}
+
+	if u.Group == "" || !hasSubscriptions(u) {
+		return
+	}
+
+	r.users[u.ID] = u
+
+	if u.Subscribed {
+		index(r.daily, u.Group, u.ID)
+	}
+	if u.SubscribedPair {
+		index(r.pair, u.Group, u.ID)
+	}
+}
+
+// hasSubscriptions reports whether the user gets any scheduled notifications.
+func hasSubscriptions(u table.User) bool {
+	return u.Subscribed || u.SubscribedPair || u.SubscribedEvening || u.SubscribedWeekly
+}
+
+func (r *registry) unset(id int) {
+	delete(r.blocked, id)
+
+	u, ok := r.users[id]
+	if !ok {
+		return
+	}
+
+	unindex(r.daily, u.Group, id)
+	unindex(r.pair, u.Group, id)
+	delete(r.users, id)
+}
+
+func index(m map[string]map[int]struct{}, group string, id int) {
+	if m[group] == nil {
+		m[group] = make(map[int]struct{})
+	}
+	m[group][id] = struct{}{}
+}
+
+func unindex(m map[string]map[int]struct{}, group string, id int) {
+	delete(m[group], id)
+	if len(m[group]) == 0 {
+		delete(m, group)
+	}
+}
+
+// user returns the registered user.
+func (r *registry) user(id int) (table.User, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	return u, ok
+}
+
+// isBlocked reports whether the user blocked the bot.
+func (r *registry) isBlocked(id int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.blocked[id]
+	return ok
+}
+
+// all returns the users subscribed to any scheduled notifications.
+func (r *registry) all() []table.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]table.User, 0, len(r.users))
+	for _, u := range r.users {
+		users = append(users, u)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	return users
+}
+
+// dailyUsers returns the users of the group subscribed to the daily schedule.
+func (r *registry) dailyUsers(group string) []table.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.collect(r.daily[group])
+}
+
+// pairByGroup returns the users subscribed to the pairs by their groups.
+func (r *registry) pairByGroup() map[string][]table.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := make(map[string][]table.User, len(r.pair))
+	for g, ids := range r.pair {
+		groups[g] = r.collect(ids)
+	}
+
+	return groups
+}
+
+func (r *registry) collect(ids map[int]struct{}) []table.User {
+	users := make([]table.User, 0, len(ids))
+	for id := range ids {
+		users = append(users, r.users[id])
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	return users
+}
@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"bot/internal/entity/table"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := newRegistry()
+	r.load([]table.User{
+		{ID: 1, Group: "a", Subscribed: true},
+		{ID: 2, Group: "a", SubscribedPair: true},
+		{ID: 3, Group: "b", Subscribed: true, Blocked: true},
+		{ID: 4, Subscribed: true},
+	})
+
+	if got := len(r.all()); got != 2 {
+		t.Fatalf("all: %d users, want 2", got)
+	}
+	if !r.isBlocked(3) || r.isBlocked(1) {
+		t.Errorf("blocked: user 3 %v, user 1 %v, want only user 3", r.isBlocked(3), r.isBlocked(1))
+	}
+
+	// the blocked user wrote again
+	r.set(table.User{ID: 3, Group: "b", Subscribed: true})
+	if r.isBlocked(3) {
+		t.Error("reactivated user is still blocked")
+	}
+	r.remove(3)
+
+	// the group changes and the pair subscription is turned on
+	r.set(table.User{ID: 1, Group: "b", Subscribed: true, SubscribedPair: true})
+
+	if got := r.dailyUsers("a"); len(got) != 0 {
+		t.Errorf("daily a: %v, want none", got)
+	}
+	if got := r.dailyUsers("b"); len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("daily b: %v, want user 1", got)
+	}
+
+	pairs := r.pairByGroup()
+	if len(pairs["a"]) != 1 || len(pairs["b"]) != 1 {
+		t.Errorf("pair: %v, want one user in a and in b", pairs)
+	}
+
+	// unsubscribing from everything drops the user
+	r.set(table.User{ID: 2, Group: "a"})
+	if _, ok := r.user(2); ok {
+		t.Error("unsubscribed user is kept")
+	}
+	if _, ok := r.pairByGroup()["a"]; ok {
+		t.Error("empty group index is kept")
+	}
+
+	r.remove(1)
+	if got := len(r.all()); got != 0 {
+		t.Errorf("all after remove: %d users, want 0", got)
+	}
+}
+
+func TestRegistry_WeeklyOnly(t *testing.T) {
+	r := newRegistry()
+	r.load([]table.User{
+		{ID: 1, Group: "a", SubscribedWeekly: true},
+		{ID: 2, Group: "a", SubscribedEvening: true},
+	})
+
+	if got := len(r.all()); got != 2 {
+		t.Fatalf("all: %d users, want 2", got)
+	}
+	if got := r.dailyUsers("a"); len(got) != 0 {
+		t.Errorf("daily a: %v, want none", got)
+	}
+	if got := r.pairByGroup(); len(got) != 0 {
+		t.Errorf("pair: %v, want none", got)
+	}
+
+	// the weekly digest is turned off
+	r.set(table.User{ID: 1, Group: "a"})
+	if _, ok := r.user(1); ok {
+		t.Error("unsubscribed user is kept")
+	}
+	if _, ok := r.user(2); !ok {
+		t.Error("evening only user is dropped")
+	}
+}
@@ -1,6 +1,8 @@
 package bot
 
 import (
+	"bot/internal/entity/table"
+	"fmt"
 	api "gopkg.in/telegram-bot-api.v4"
 )
 
@@ -20,18 +22,44 @@ const (
 
 // Group of constants for handling messages from user.
 const (
-	schedule             = "Расписание"
-	silence              = "silence"
-	start                = "start"
-	info                 = "info"
-	subgroup             = "subgroup"
-	group                = "group"
-	changeGroup          = "changeGroup"
-	settings             = "settings"
-	sendSchedule         = "sendSchedule"
-	sendPair             = "sendPair"
-	changeDailySubscribe = "changeDailySubscribe"
-	changePairSubscribe  = "changePairSubscribe"
+	schedule               = "Расписание"
+	week                   = "week"
+	today                  = "today"
+	nowView                = "now"
+	dailyTime              = "dailyTime"
+	timeZone               = "timeZone"
+	evening                = "evening"
+	reminders              = "reminders"
+	remindBefore           = "remindBefore"
+	remindMode             = "remindMode"
+	mute                   = "mute"
+	unmute                 = "unmute"
+	quiet                  = "quiet"
+	followList             = "follows"
+	followOpen             = "followOpen"
+	followDaily            = "followDaily"
+	followPair             = "followPair"
+	followPrimary          = "followPrimary"
+	unfollow               = "unfollow"
+	deleteNote             = "deleteNote"
+	deleteEvent            = "deleteEvent"
+	deleteHomework         = "deleteHomework"
+	deleteNotice           = "deleteNotice"
+	silence                = "silence"
+	start                  = "start"
+	info                   = "info"
+	subgroup               = "subgroup"
+	group                  = "group"
+	changeGroup            = "changeGroup"
+	settings               = "settings"
+	sendSchedule           = "sendSchedule"
+	sendPair               = "sendPair"
+	sendEvening            = "sendEvening"
+	sendWeekly             = "sendWeekly"
+	changeDailySubscribe   = "changeDailySubscribe"
+	changePairSubscribe    = "changePairSubscribe"
+	changeEveningSubscribe = "changeEveningSubscribe"
+	changeWeeklySubscribe  = "changeWeeklySubscribe"
 )
 
 var groupButtons = make([][]api.InlineKeyboardButton, 0)
@@ -48,26 +76,22 @@ var (
 			api.NewInlineKeyboardButtonData("Настройки ⚙️", settings),
 		),
 		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Сейчас ⏱", nowView),
 			api.NewInlineKeyboardButtonData("Помощь ℹ️", info),
 		),
 	)
 
-	scheduleKeyboard = api.NewInlineKeyboardMarkup(
+	nowKeyboard = api.NewInlineKeyboardMarkup(
 		api.NewInlineKeyboardRow(
-			api.NewInlineKeyboardButtonData("Пн", schedule+"::0"),
-			api.NewInlineKeyboardButtonData("Вт", schedule+"::1"),
-			api.NewInlineKeyboardButtonData("Ср", schedule+"::2"),
-			api.NewInlineKeyboardButtonData("Чт", schedule+"::3"),
-			api.NewInlineKeyboardButtonData("Пт", schedule+"::4"),
-		),
-		api.NewInlineKeyboardRow(
-			api.NewInlineKeyboardButtonURL("Проверить", "https://www.spbkap.ru/studentam/raspisanie-zanyatiy/"),
-			api.NewInlineKeyboardButtonData("Назад", start),
+			api.NewInlineKeyboardButtonData("Обновить", nowView),
+			api.NewInlineKeyboardButtonData("К расписанию", schedule),
 		),
 	)
+
 	nextPairKeyboard = api.NewInlineKeyboardMarkup(
 		api.NewInlineKeyboardRow(
 			api.NewInlineKeyboardButtonData("Заглушить до конца дня", silence),
+			api.NewInlineKeyboardButtonData("Еще…", mute),
 		),
 
 		api.NewInlineKeyboardRow(
@@ -85,18 +109,105 @@ var (
 	settingsKeyboard = api.NewInlineKeyboardMarkup(
 		api.NewInlineKeyboardRow(
 			api.NewInlineKeyboardButtonData("Изменить группу", changeGroup),
+			api.NewInlineKeyboardButtonData("Мои группы", followList),
 		),
 		api.NewInlineKeyboardRow(
 			api.NewInlineKeyboardButtonData("Отправка расписания", sendSchedule),
+			api.NewInlineKeyboardButtonData("Время отправки", dailyTime),
 		),
 		api.NewInlineKeyboardRow(
 			api.NewInlineKeyboardButtonData("Отправка пар", sendPair),
+			api.NewInlineKeyboardButtonData("Напоминания", reminders),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Вечером на завтра", sendEvening),
+			api.NewInlineKeyboardButtonData("Неделя вперед", sendWeekly),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Тишина 🔕", mute),
+			api.NewInlineKeyboardButtonData("Часовой пояс", timeZone),
 		),
 		api.NewInlineKeyboardRow(
 			api.NewInlineKeyboardButtonData("Назад", start),
 		),
 	)
 
+	muteKeyboard = api.NewInlineKeyboardMarkup(
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("1 час", mute+"::"+muteHour),
+			api.NewInlineKeyboardButtonData("Сегодня", mute+"::"+muteDay),
+			api.NewInlineKeyboardButtonData("Эта неделя", mute+"::"+muteWeek),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("До даты…", mute+"::"+muteDate),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Тихие часы 22–8", quiet+"::22-8"),
+			api.NewInlineKeyboardButtonData("23–9", quiet+"::23-9"),
+			api.NewInlineKeyboardButtonData("Выкл", quiet+"::"+quietOff),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Включить уведомления", unmute),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Назад", settings),
+		),
+	)
+
+	unmuteKeyboard = api.NewInlineKeyboardMarkup(
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Включить уведомления", unmute),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("К расписанию", schedule),
+		),
+	)
+
+	timeZoneKeyboard = newTimeZoneKeyboard()
+
+	dailyTimeKeyboard = api.NewInlineKeyboardMarkup(
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("7:00", dailyTime+"::7"),
+			api.NewInlineKeyboardButtonData("8:00", dailyTime+"::8"),
+			api.NewInlineKeyboardButtonData("9:00", dailyTime+"::9"),
+			api.NewInlineKeyboardButtonData("10:00", dailyTime+"::10"),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Накануне вечером (20:00)", dailyTime+"::"+evening),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Назад", settings),
+		),
+	)
+
+	remindersKeyboard = api.NewInlineKeyboardMarkup(
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("0", remindBefore+"::0"),
+			api.NewInlineKeyboardButtonData("5", remindBefore+"::5"),
+			api.NewInlineKeyboardButtonData("10", remindBefore+"::10"),
+			api.NewInlineKeyboardButtonData("15", remindBefore+"::15"),
+			api.NewInlineKeyboardButtonData("20", remindBefore+"::20"),
+			api.NewInlineKeyboardButtonData("30", remindBefore+"::30"),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Все пары", fmt.Sprintf("%s::%d", remindMode, table.RemindAll)),
+			api.NewInlineKeyboardButtonData("Первая пара", fmt.Sprintf("%s::%d", remindMode, table.RemindFirst)),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("При смене кабинета", fmt.Sprintf("%s::%d", remindMode, table.RemindRoomChange)),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Назад", settings),
+		),
+	)
+
+	followsKeyboard = api.NewInlineKeyboardMarkup(
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("К расписанию", schedule),
+			api.NewInlineKeyboardButtonData("Назад", settings),
+		),
+	)
+
 	submitDailyScheduleSubscribeKeyboard = api.NewInlineKeyboardMarkup(
 		api.NewInlineKeyboardRow(
 			api.NewInlineKeyboardButtonData("Подтвердить", changeDailySubscribe),
@@ -111,6 +222,20 @@ var (
 		),
 	)
 
+	submitEveningSubscribeKeyboard = api.NewInlineKeyboardMarkup(
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Подтвердить", changeEveningSubscribe),
+			api.NewInlineKeyboardButtonData("Назад", settings),
+		),
+	)
+
+	submitWeeklySubscribeKeyboard = api.NewInlineKeyboardMarkup(
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Подтвердить", changeWeeklySubscribe),
+			api.NewInlineKeyboardButtonData("Назад", settings),
+		),
+	)
+
 	subGroupsKeyboard = api.NewInlineKeyboardMarkup(
 		api.NewInlineKeyboardRow(
 			api.NewInlineKeyboardButtonData("1", subgroup+"::1"),
@@ -124,3 +249,63 @@ var (
 		),
 	)
 )
+
+// newScheduleKeyboard returns the keyboard of the day view. Day buttons stay
+// in the shown week, arrows move to the same weekday of the previous or the
+// next week. followID is the followed group being shown, zero for the primary
+// one, follows are offered to switch between the groups.
+func newScheduleKeyboard(day int, weekShift int, followID int, follows []table.Follow) api.InlineKeyboardMarkup {
+	data := func(d int, w int, f int) string {
+		if f == 0 {
+			return fmt.Sprintf("%s::%d::%d", schedule, d, w)
+		}
+		return fmt.Sprintf("%s::%d::%d::%d", schedule, d, w, f)
+	}
+
+	dayButton := func(text string, d int) api.InlineKeyboardButton {
+		return api.NewInlineKeyboardButtonData(text, data(d, weekShift, followID))
+	}
+
+	rows := [][]api.InlineKeyboardButton{
+		api.NewInlineKeyboardRow(
+			dayButton("Пн", 0),
+			dayButton("Вт", 1),
+			dayButton("Ср", 2),
+			dayButton("Чт", 3),
+			dayButton("Пт", 4),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("◀", data(day, weekShift-1, followID)),
+			api.NewInlineKeyboardButtonData("Сегодня", fmt.Sprintf("%s::%s::0::%d", schedule, today, followID)),
+			api.NewInlineKeyboardButtonData("▶", data(day, weekShift+1, followID)),
+		),
+	}
+
+	if len(follows) > 0 {
+		var row []api.InlineKeyboardButton
+		if followID != 0 {
+			row = append(row, api.NewInlineKeyboardButtonData("Моя группа", data(day, weekShift, 0)))
+		}
+
+		for _, f := range follows {
+			if f.ID == followID {
+				continue
+			}
+			row = append(row, api.NewInlineKeyboardButtonData(followName(f), data(day, weekShift, f.ID)))
+		}
+
+		rows = append(rows, row)
+	}
+
+	rows = append(rows,
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonData("Неделя", week),
+		),
+		api.NewInlineKeyboardRow(
+			api.NewInlineKeyboardButtonURL("Проверить", "https://www.spbkap.ru/studentam/raspisanie-zanyatiy/"),
+			api.NewInlineKeyboardButtonData("Назад", start),
+		),
+	)
+
+	return api.NewInlineKeyboardMarkup(rows...)
+}
@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"bot/internal/bells"
+	"bot/internal/entity/table"
+	"fmt"
+	api "gopkg.in/telegram-bot-api.v4"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeZones are the zones offered in the settings, others are set with /tz.
+var timeZones = []struct {
+	title string
+	name  string
+}{
+	{"Калининград", "Europe/Kaliningrad"},
+	{"Москва", "Europe/Moscow"},
+	{"Самара", "Europe/Samara"},
+	{"Екатеринбург", "Asia/Yekaterinburg"},
+	{"Омск", "Asia/Omsk"},
+	{"Новосибирск", "Asia/Novosibirsk"},
+	{"Иркутск", "Asia/Irkutsk"},
+	{"Якутск", "Asia/Yakutsk"},
+	{"Владивосток", "Asia/Vladivostok"},
+}
+
+// locations caches the loaded time zones by name.
+var locations sync.Map
+
+// userLoc returns the user's time zone, the college's one if it is not set
+// or unknown.
+func userLoc(user table.User) *time.Location {
+	if user.TimeZone == "" {
+		return mskLoc
+	}
+
+	if loc, ok := locations.Load(user.TimeZone); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(user.TimeZone)
+	if err != nil {
+		return mskLoc
+	}
+
+	locations.Store(user.TimeZone, loc)
+
+	return loc
+}
+
+// localTime formats the minute of the day of the date, counted in the
+// college's zone as the bells are, as the time in loc.
+func localTime(date time.Time, minute int, loc *time.Location) string {
+	d := date.In(mskLoc)
+	t := time.Date(d.Year(), d.Month(), d.Day(), 0, minute, 0, 0, mskLoc).In(loc)
+
+	return bells.FormatMinute(t.Hour()*60 + t.Minute())
+}
+
+// collegeDate returns the midnight of the date of t in the college's zone, the
+// schedule of a day is looked up by it.
+func collegeDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, mskLoc)
+}
+
+// timeZoneText describes the user's time zone.
+func timeZoneText(user table.User) string {
+	loc := userLoc(user)
+	if loc == mskLoc {
+		return "московское, как в колледже"
+	}
+
+	_, offset := time.Now().In(loc).Zone()
+	return fmt.Sprintf("%s (UTC%+d)", loc.String(), offset/3600)
+}
+
+func (b *Bot) showTimeZone(user table.User) {
+	text := fmt.Sprintf("Время в сообщениях: %s.\n\nВыбери часовой пояс или напиши /tz Asia/Tomsk:", timeZoneText(user))
+	b.send(newMsgForUser(text, user.ChatID, &timeZoneKeyboard))
+}
+
+// changeTimeZone sets the user's time zone by its IANA name.
+func (b *Bot) changeTimeZone(user table.User, name string) {
+	if _, err := time.LoadLocation(name); err != nil || name == "" || name == "Local" {
+		b.send(newMsgForUser("Не знаю такой часовой пояс. Например: /tz Asia/Tomsk", user.ChatID, &timeZoneKeyboard))
+		return
+	}
+
+	user.TimeZone = name
+	if name == mskLoc.String() {
+		user.TimeZone = ""
+	}
+
+	if err := b.saveUser(user); err != nil {
+		b.logger.Warn(fmt.Sprintf("changeTimeZone save error: %v", err.Error()))
+	}
+
+	b.send(newMsgForUser(fmt.Sprintf("Готово! Время в сообщениях: %s.", timeZoneText(user)), user.ChatID, &toScheduleKeyboard))
+}
+
+func (b *Bot) handleTimeZoneCommand(msg *api.Message) {
+	user, ok := b.commandUser(msg)
+	if !ok {
+		return
+	}
+
+	if name := strings.TrimSpace(msg.CommandArguments()); name != "" {
+		b.changeTimeZone(user, name)
+		return
+	}
+
+	b.showTimeZone(user)
+}
+
+func newTimeZoneKeyboard() api.InlineKeyboardMarkup {
+	var rows [][]api.InlineKeyboardButton
+	var row []api.InlineKeyboardButton
+
+	for _, tz := range timeZones {
+		row = append(row, api.NewInlineKeyboardButtonData(tz.title, timeZone+"::"+tz.name))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+
+	if len(row) != 0 {
+		rows = append(rows, row)
+	}
+
+	rows = append(rows, api.NewInlineKeyboardRow(api.NewInlineKeyboardButtonData("Назад", settings)))
+
+	return api.NewInlineKeyboardMarkup(rows...)
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"bot/internal/entity/table"
+	"testing"
+	"time"
+)
+
+func loadMsk(t *testing.T) {
+	t.Helper()
+
+	var err error
+	if mskLoc, err = time.LoadLocation("Europe/Moscow"); err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+}
+
+func TestLocalTime(t *testing.T) {
+	loadMsk(t)
+
+	date := time.Date(2024, time.September, 2, 12, 0, 0, 0, mskLoc)
+	user := table.User{TimeZone: "Asia/Yekaterinburg"}
+
+	if got := localTime(date, 9*60, userLoc(user)); got != "11:00" {
+		t.Errorf("localTime() = %v, want 11:00", got)
+	}
+	if got := localTime(date, 9*60, userLoc(table.User{})); got != "9:00" {
+		t.Errorf("localTime() = %v, want 9:00", got)
+	}
+	if got := userLoc(table.User{TimeZone: "Mars/Olympus"}); got != mskLoc {
+		t.Errorf("userLoc() = %v, want the college's zone", got)
+	}
+}
+
+func TestDailyDate_TimeZone(t *testing.T) {
+	loadMsk(t)
+
+	// 22:00 in Moscow is already the next day in Vladivostok
+	now := time.Date(2024, time.September, 2, 22, 0, 0, 0, mskLoc)
+	user := table.User{TimeZone: "Asia/Vladivostok", DailyHour: 8}
+
+	date, at := dailyDate(user, now)
+	if want := time.Date(2024, time.September, 3, 0, 0, 0, 0, mskLoc); !date.Equal(want) {
+		t.Errorf("dailyDate() date = %v, want %v", date, want)
+	}
+	if want := time.Date(2024, time.September, 3, 1, 0, 0, 0, mskLoc); !at.Equal(want) {
+		t.Errorf("dailyDate() at = %v, want %v", at, want)
+	}
+
+	// quiet hours are in the user's zone: 23:00 in Moscow is 6:00 there
+	user.QuietFrom, user.QuietTo = 23, 7
+	if !inQuietHours(user, now.Add(time.Hour)) {
+		t.Errorf("inQuietHours() = false at 6:00 local time")
+	}
+}
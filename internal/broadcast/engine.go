@@ -0,0 +1,390 @@
+// Package broadcast sends messages to Telegram through a queue, respecting
+// the flood limits and retrying when Telegram asks to.
+package broadcast
+
+import (
+	"bot/internal/entity/table"
+	"bot/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	api "gopkg.in/telegram-bot-api.v4"
+	"sync"
+	"time"
+)
+
+// Telegram allows about 30 messages per second overall and about one per
+// second to the same chat, the limits are kept a bit lower.
+const (
+	globalRate  = 25
+	globalBurst = 25
+	chatRate    = 1
+	chatBurst   = 3
+)
+
+const (
+	// workers is the number of the senders, messages to the same chat are
+	// always sent by the same one so they keep their order within a lane.
+	workers   = 4
+	queueSize = 1000
+	// maxAttempts is the number of tries to send a message when Telegram
+	// answers with 429 Too Many Requests.
+	maxAttempts = 3
+	// progressStep is the number of recipients after which the author of the
+	// broadcast is told the progress.
+	progressStep = 100
+	// maxChatBuckets is the number of chats whose limits are kept before idle
+	// ones are dropped.
+	maxChatBuckets = 10000
+)
+
+// Sender sends messages to Telegram, *api.BotAPI implements it.
+type Sender interface {
+	Send(c api.Chattable) (api.Message, error)
+}
+
+// Message is a message of the broadcast to one recipient.
+type Message struct {
+	UserID int
+	ChatID int64
+	Msg    api.Chattable
+}
+
+type job struct {
+	chatID    int64
+	msg       api.Chattable
+	recipient *table.BroadcastRecipient
+}
+
+// progress is the state of a running broadcast.
+type progress struct {
+	broadcast  table.Broadcast
+	authorChat int64
+}
+
+// Engine queues the messages and sends them within the limits.
+type Engine struct {
+	sender  Sender
+	storage storage.BroadcastRepository
+	logger  *zap.Logger
+
+	global *bucket
+
+	mu      sync.Mutex
+	chats   map[int64]*bucket
+	running map[int]*progress
+
+	// direct are the queues of the replies and the scheduled messages, they
+	// are sent before the broadcasts waiting in bulk.
+	direct []chan job
+	bulk   []chan job
+
+	// undeliverable is called when the chat can't receive messages anymore.
+	undeliverable func(chatID int64, reason Reason)
+}
+
+// New creates a new engine, Start must be called before messages are sent.
+func New(sender Sender, storage storage.BroadcastRepository, logger *zap.Logger) *Engine {
+	e := &Engine{
+		sender:  sender,
+		storage: storage,
+		logger:  logger,
+		global:  newBucket(globalRate, globalBurst),
+		chats:   make(map[int64]*bucket),
+		running: make(map[int]*progress),
+		direct:  make([]chan job, workers),
+		bulk:    make([]chan job, workers),
+	}
+
+	for i := 0; i < workers; i++ {
+		e.direct[i] = make(chan job, queueSize)
+		e.bulk[i] = make(chan job, queueSize)
+	}
+
+	return e
+}
+
+// OnUndeliverable sets the function called when a message can't be delivered
+// to the chat because the user blocked the bot, deleted the account or the
+// chat is gone. It must be set before Start.
+func (e *Engine) OnUndeliverable(f func(chatID int64, reason Reason)) {
+	e.undeliverable = f
+}
+
+// Start starts the senders, they stop when the context is done.
+func (e *Engine) Start(ctx context.Context) {
+	for i := 0; i < workers; i++ {
+		go e.work(ctx, e.direct[i], e.bulk[i])
+	}
+}
+
+// Send queues the message ahead of the broadcasts, errors are only logged. It
+// never waits for the queue, so it is safe to call from the update loop.
+func (e *Engine) Send(c api.Chattable) {
+	j := job{chatID: ChatID(c), msg: c}
+	q := e.direct[shard(j.chatID)]
+
+	select {
+	case q <- j:
+	default:
+		e.logger.Warn(fmt.Sprintf("send to %d: queue is full", j.chatID))
+		go func() { q <- j }()
+	}
+}
+
+// Broadcast records the broadcast and queues its messages. The outcome for
+// every recipient is saved, the progress and the result are sent to the
+// author's chat.
+func (e *Engine) Broadcast(authorID int, authorChat int64, text string, msgs []Message) (table.Broadcast, error) {
+	record := table.Broadcast{
+		AuthorID:  authorID,
+		Text:      text,
+		Total:     len(msgs),
+		CreatedAt: time.Now(),
+	}
+
+	if err := e.storage.AddBroadcast(&record); err != nil {
+		return record, fmt.Errorf("add broadcast: %w", err)
+	}
+
+	recipients := make([]table.BroadcastRecipient, len(msgs))
+	for i, m := range msgs {
+		recipients[i] = table.BroadcastRecipient{
+			BroadcastID: record.ID,
+			UserID:      m.UserID,
+			ChatID:      m.ChatID,
+			Status:      table.RecipientPending,
+		}
+	}
+
+	if err := e.storage.AddBroadcastRecipients(recipients); err != nil {
+		return record, fmt.Errorf("add broadcast recipients: %w", err)
+	}
+
+	if len(msgs) == 0 {
+		e.finish(progress{broadcast: record, authorChat: authorChat})
+		return record, nil
+	}
+
+	e.mu.Lock()
+	e.running[record.ID] = &progress{broadcast: record, authorChat: authorChat}
+	e.mu.Unlock()
+
+	// the queues may be full, the caller must not wait for them
+	go func() {
+		for i, m := range msgs {
+			e.bulk[shard(m.ChatID)] <- job{chatID: m.ChatID, msg: m.Msg, recipient: &recipients[i]}
+		}
+	}()
+
+	return record, nil
+}
+
+// shard returns the index of the worker the chat's messages are sent by.
+func shard(chatID int64) int {
+	return int(uint64(chatID) % workers)
+}
+
+// work sends the messages of the worker, the direct ones go first and the
+// broadcasts only when there are none.
+func (e *Engine) work(ctx context.Context, direct, bulk chan job) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-direct:
+			e.deliver(ctx, j)
+			continue
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-direct:
+			e.deliver(ctx, j)
+		case j := <-bulk:
+			e.deliver(ctx, j)
+		}
+	}
+}
+
+func (e *Engine) deliver(ctx context.Context, j job) {
+	attempts, err := e.sendWithRetry(ctx, j)
+
+	if err != nil {
+		reason := Classify(err)
+		e.logger.Warn(fmt.Sprintf("send to %d error (%s): %v", j.chatID, reason, err.Error()))
+
+		if reason.Undeliverable() && e.undeliverable != nil {
+			e.undeliverable(j.chatID, reason)
+		}
+	}
+
+	if j.recipient == nil {
+		return
+	}
+
+	e.record(*j.recipient, attempts, err)
+}
+
+// sendWithRetry sends the message within the limits, on 429 it waits as long
+// as Telegram asks and tries again.
+func (e *Engine) sendWithRetry(ctx context.Context, j job) (attempts int, err error) {
+	for attempts < maxAttempts {
+		chat := e.chatBucket(j.chatID)
+		if chat != nil {
+			if err = chat.wait(ctx); err != nil {
+				return attempts, err
+			}
+		}
+
+		if err = e.global.wait(ctx); err != nil {
+			return attempts, err
+		}
+
+		attempts++
+
+		_, err = e.sender.Send(j.msg)
+
+		retryAfter, ok := RetryAfter(err)
+		if !ok {
+			return attempts, err
+		}
+
+		e.logger.Warn(fmt.Sprintf("send to %d: too many requests, retry after %v", j.chatID, retryAfter))
+
+		// it is not known whether the limit is for the chat or for the
+		// bot, so both wait
+		until := time.Now().Add(retryAfter)
+		e.global.pause(until)
+		if chat != nil {
+			chat.pause(until)
+		}
+	}
+
+	return attempts, err
+}
+
+// RetryAfter returns how long Telegram asked to wait if the error is 429 Too
+// Many Requests.
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr api.Error
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(apiErr.RetryAfter) * time.Second, true
+}
+
+// chatBucket returns the limiter of the chat, nil if the chat is unknown.
+func (e *Engine) chatBucket(chatID int64) *bucket {
+	if chatID == 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if b, ok := e.chats[chatID]; ok {
+		return b
+	}
+
+	if len(e.chats) >= maxChatBuckets {
+		now := time.Now()
+		for id, b := range e.chats {
+			if b.full(now) {
+				delete(e.chats, id)
+			}
+		}
+	}
+
+	b := newBucket(chatRate, chatBurst)
+	e.chats[chatID] = b
+
+	return b
+}
+
+// record saves the outcome for the recipient and updates the progress of the
+// broadcast.
+func (e *Engine) record(r table.BroadcastRecipient, attempts int, err error) {
+	r.Attempts = attempts
+	if err != nil {
+		r.Status = table.RecipientFailed
+		r.Reason = string(Classify(err))
+		r.Error = err.Error()
+	} else {
+		r.Status = table.RecipientSent
+		r.SentAt = time.Now()
+	}
+
+	if err := e.storage.SaveBroadcastRecipient(r); err != nil {
+		e.logger.Warn(fmt.Sprintf("save broadcast recipient error: %v", err.Error()))
+	}
+
+	e.mu.Lock()
+	p, ok := e.running[r.BroadcastID]
+	if !ok {
+		e.mu.Unlock()
+		return
+	}
+
+	if err != nil {
+		p.broadcast.Failed++
+	} else {
+		p.broadcast.Sent++
+	}
+
+	done := p.broadcast.Sent + p.broadcast.Failed
+	if done == p.broadcast.Total {
+		delete(e.running, r.BroadcastID)
+	}
+	state := *p
+	e.mu.Unlock()
+
+	switch {
+	case done == state.broadcast.Total:
+		e.finish(state)
+	case done%progressStep == 0:
+		if err := e.storage.SaveBroadcast(state.broadcast); err != nil {
+			e.logger.Warn(fmt.Sprintf("save broadcast error: %v", err.Error()))
+		}
+		e.report(state.authorChat, fmt.Sprintf("Рассылка #%d: %d из %d, ошибок: %d",
+			state.broadcast.ID, done, state.broadcast.Total, state.broadcast.Failed))
+	}
+}
+
+func (e *Engine) finish(p progress) {
+	p.broadcast.FinishedAt = time.Now()
+
+	if err := e.storage.SaveBroadcast(p.broadcast); err != nil {
+		e.logger.Warn(fmt.Sprintf("save broadcast error: %v", err.Error()))
+	}
+
+	e.report(p.authorChat, fmt.Sprintf("Рассылка #%d завершена: доставлено %d из %d, ошибок: %d",
+		p.broadcast.ID, p.broadcast.Sent, p.broadcast.Total, p.broadcast.Failed))
+}
+
+// report tells the author about the broadcast.
+func (e *Engine) report(chatID int64, text string) {
+	if chatID == 0 {
+		return
+	}
+
+	e.Send(api.NewMessage(chatID, text))
+}
+
+// ChatID returns the chat the message is sent to, zero if it is not known.
+func ChatID(c api.Chattable) int64 {
+	switch m := c.(type) {
+	case api.MessageConfig:
+		return m.ChatID
+	case api.EditMessageTextConfig:
+		return m.ChatID
+	case api.PhotoConfig:
+		return m.ChatID
+	}
+
+	return 0
+}
@@ -0,0 +1,247 @@
+package broadcast
+
+import (
+	"bot/internal/entity/table"
+	"bot/internal/storage"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	api "gopkg.in/telegram-bot-api.v4"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSender struct {
+	mu sync.Mutex
+	// fails maps the chat to the errors returned before the message is sent.
+	fails map[int64][]error
+	sent  []int64
+}
+
+func (s *fakeSender) Send(c api.Chattable) (api.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chatID := ChatID(c)
+	if errs := s.fails[chatID]; len(errs) > 0 {
+		s.fails[chatID] = errs[1:]
+		return api.Message{}, errs[0]
+	}
+
+	s.sent = append(s.sent, chatID)
+
+	return api.Message{}, nil
+}
+
+func TestBucket(t *testing.T) {
+	b := newBucket(2, 2)
+	now := time.Now()
+
+	if d := b.reserve(now); d != 0 {
+		t.Fatalf("first token: wait %v", d)
+	}
+	if d := b.reserve(now); d != 0 {
+		t.Fatalf("second token: wait %v", d)
+	}
+	if d := b.reserve(now); d != 500*time.Millisecond {
+		t.Fatalf("empty bucket: wait %v, want 500ms", d)
+	}
+	if d := b.reserve(now.Add(500 * time.Millisecond)); d != 0 {
+		t.Fatalf("refilled bucket: wait %v", d)
+	}
+
+	b.pause(now.Add(2 * time.Second))
+	if d := b.reserve(now.Add(time.Second)); d != time.Second {
+		t.Fatalf("paused bucket: wait %v, want 1s", d)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		err  error
+		want time.Duration
+		ok   bool
+	}{
+		{err: nil},
+		{err: errors.New("Bad Request: chat not found")},
+		{err: api.Error{Message: "Too Many Requests", ResponseParameters: api.ResponseParameters{RetryAfter: 3}}, want: 3 * time.Second, ok: true},
+	}
+
+	for _, tt := range tests {
+		got, ok := RetryAfter(tt.err)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("RetryAfter(%v) = %v, %v, want %v, %v", tt.err, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestEngine_Broadcast(t *testing.T) {
+	store, err := storage.New(storage.Config{DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := &fakeSender{fails: map[int64][]error{
+		2: {api.Error{Message: "Too Many Requests", ResponseParameters: api.ResponseParameters{RetryAfter: 1}}},
+		3: {errors.New("Forbidden: bot was blocked by the user")},
+	}}
+
+	e := New(sender, store, zap.NewNop())
+
+	undeliverable := make(chan int64, 1)
+	e.OnUndeliverable(func(chatID int64, reason Reason) {
+		undeliverable <- chatID
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e.Start(ctx)
+
+	var msgs []Message
+	for id := int64(1); id <= 3; id++ {
+		msgs = append(msgs, Message{UserID: int(id), ChatID: id, Msg: api.NewMessage(id, "hi")})
+	}
+
+	record, err := e.Broadcast(1, 0, "hi", msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := store.GetBroadcastByID(record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !got.FinishedAt.IsZero() {
+			if got.Sent != 2 || got.Failed != 1 {
+				t.Fatalf("sent %d, failed %d, want 2 and 1", got.Sent, got.Failed)
+			}
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("broadcast is not finished")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if status := recipientStatus(t, store, record.ID, 3); status != table.RecipientFailed {
+		t.Errorf("blocked recipient status %q, want %q", status, table.RecipientFailed)
+	}
+
+	select {
+	case chatID := <-undeliverable:
+		if chatID != 3 {
+			t.Errorf("undeliverable chat %d, want 3", chatID)
+		}
+	default:
+		t.Error("blocked chat is not reported")
+	}
+}
+
+func TestEngine_SendBeforeBroadcast(t *testing.T) {
+	sender := &fakeSender{}
+	e := New(sender, storage.NewMemory(), zap.NewNop())
+
+	// all the chats go to the first worker
+	var msgs []Message
+	for id := int64(workers); id <= 3*workers; id += workers {
+		msgs = append(msgs, Message{UserID: int(id), ChatID: id, Msg: api.NewMessage(id, "hi")})
+	}
+
+	if _, err := e.Broadcast(1, 0, "hi", msgs); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(e.bulk[0]) < len(msgs) {
+		if time.Now().After(deadline) {
+			t.Fatal("broadcast is not queued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	e.Send(api.NewMessage(100*workers, "reply"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e.Start(ctx)
+
+	for {
+		sender.mu.Lock()
+		sent := append([]int64(nil), sender.sent...)
+		sender.mu.Unlock()
+
+		if len(sent) > 0 {
+			if sent[0] != 100*workers {
+				t.Fatalf("first sent to %d, want the reply to %d", sent[0], 100*workers)
+			}
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("nothing is sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEngine_SendFullQueue(t *testing.T) {
+	e := New(&fakeSender{}, storage.NewMemory(), zap.NewNop())
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i <= queueSize; i++ {
+			e.Send(api.NewMessage(1, "hi"))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send blocks on a full queue")
+	}
+}
+
+func recipientStatus(t *testing.T, store *storage.Storage, broadcastID int, chatID int64) string {
+	t.Helper()
+
+	rs, err := store.GetBroadcastRecipients(broadcastID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range rs {
+		if r.ChatID == chatID {
+			return r.Status
+		}
+	}
+
+	return ""
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Reason
+	}{
+		{err: nil, want: ReasonNone},
+		{err: errors.New("Forbidden: bot was blocked by the user"), want: ReasonBlocked},
+		{err: errors.New("Forbidden: bot was kicked from the supergroup chat"), want: ReasonBlocked},
+		{err: errors.New("Forbidden: user is deactivated"), want: ReasonDeactivated},
+		{err: errors.New("Bad Request: chat not found"), want: ReasonChatNotFound},
+		{err: api.Error{Message: "Too Many Requests", ResponseParameters: api.ResponseParameters{RetryAfter: 5}}, want: ReasonFlood},
+		{err: errors.New("Bad Request: message is not modified"), want: ReasonOther},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
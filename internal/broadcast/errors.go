@@ -0,0 +1,56 @@
+package broadcast
+
+import (
+	"strings"
+)
+
+// Reason is the class of a send failure.
+type Reason string
+
+// Group of send failure reasons.
+const (
+	ReasonNone Reason = ""
+	// ReasonBlocked means the user blocked the bot or the bot was removed
+	// from the chat.
+	ReasonBlocked Reason = "blocked"
+	// ReasonChatNotFound means the chat doesn't exist anymore.
+	ReasonChatNotFound Reason = "chat not found"
+	// ReasonDeactivated means the user deleted the account.
+	ReasonDeactivated Reason = "deactivated"
+	// ReasonFlood means Telegram asked to retry later.
+	ReasonFlood Reason = "flood"
+	ReasonOther Reason = "other"
+)
+
+// Classify returns the reason of the send failure.
+func Classify(err error) Reason {
+	if err == nil {
+		return ReasonNone
+	}
+
+	if _, ok := RetryAfter(err); ok {
+		return ReasonFlood
+	}
+
+	text := strings.ToLower(err.Error())
+
+	switch {
+	case strings.Contains(text, "bot was blocked by the user"),
+		strings.Contains(text, "bot was kicked"),
+		strings.Contains(text, "bot is not a member"),
+		strings.Contains(text, "bot can't initiate conversation"):
+		return ReasonBlocked
+	case strings.Contains(text, "user is deactivated"):
+		return ReasonDeactivated
+	case strings.Contains(text, "chat not found"):
+		return ReasonChatNotFound
+	}
+
+	return ReasonOther
+}
+
+// Undeliverable reports whether messages to the chat will never be delivered
+// until the user writes to the bot again.
+func (r Reason) Undeliverable() bool {
+	return r == ReasonBlocked || r == ReasonChatNotFound || r == ReasonDeactivated
+}
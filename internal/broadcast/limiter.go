@@ -0,0 +1,85 @@
+package broadcast
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket: tokens are added at the rate per second up to the
+// burst, every message takes one.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// pausedUntil is set when Telegram asks to retry later.
+	pausedUntil time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// reserve takes a token if there is one and returns zero, otherwise it returns
+// how long to wait before trying again.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Before(b.pausedUntil) {
+		return b.pausedUntil.Sub(now)
+	}
+
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// pause stops giving tokens until the time.
+func (b *bucket) pause(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// full reports whether the bucket has been idle long enough to be refilled.
+func (b *bucket) full(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst && !now.Before(b.pausedUntil)
+}
+
+// wait blocks until a token is taken or the context is done.
+func (b *bucket) wait(ctx context.Context) error {
+	for {
+		d := b.reserve(time.Now())
+		if d == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package calendar tells the study days apart from the days off: weekends,
+// holidays, vacations and the practice periods of groups.
+package calendar
+
+import (
+	"bot/internal/constant"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Holiday is a day off that would be a study day otherwise.
+type Holiday struct {
+	Date  string `json:"date"`
+	Title string `json:"title"`
+}
+
+// Workday is a weekend day transferred to be a working one, the pairs of the
+// weekday As ("monday", ...) are held on it.
+type Workday struct {
+	Date string `json:"date"`
+	As   string `json:"as"`
+}
+
+// Period is a range of days off, both ends included. Empty Groups means all
+// groups.
+type Period struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Title  string   `json:"title"`
+	Groups []string `json:"groups"`
+}
+
+// Config is the academic calendar.
+type Config struct {
+	Holidays  []Holiday `json:"holidays"`
+	Workdays  []Workday `json:"workdays"`
+	Vacations []Period  `json:"vacations"`
+	Practice  []Period  `json:"practice"`
+}
+
+// Day describes a date for a group.
+type Day struct {
+	// Study is true if there are pairs on the day.
+	Study bool
+	// Weekday is the weekday whose pairs are held.
+	Weekday time.Weekday
+	// Reason is why there are no pairs, empty on weekends.
+	Reason string
+}
+
+// Off describes the day off: "выходной день" and the reason if any.
+func (d Day) Off() string {
+	if d.Reason == "" {
+		return "выходной день"
+	}
+
+	return fmt.Sprintf("выходной день (%s)", d.Reason)
+}
+
+type period struct {
+	from   time.Time
+	to     time.Time
+	title  string
+	groups map[string]struct{}
+}
+
+func (p period) contains(group string, date time.Time) bool {
+	if date.Before(p.from) || date.After(p.to) {
+		return false
+	}
+
+	if len(p.groups) == 0 {
+		return true
+	}
+
+	_, ok := p.groups[group]
+	return ok
+}
+
+// Calendar answers whether a date is a study day. A nil calendar knows only
+// the weekends.
+type Calendar struct {
+	holidays  map[string]string
+	workdays  map[string]time.Weekday
+	vacations []period
+	practice  []period
+}
+
+// New parses the config.
+func New(c Config) (*Calendar, error) {
+	cal := &Calendar{
+		holidays: make(map[string]string, len(c.Holidays)),
+		workdays: make(map[string]time.Weekday, len(c.Workdays)),
+	}
+
+	for _, h := range c.Holidays {
+		if _, err := time.Parse(constant.DateLayout, h.Date); err != nil {
+			return nil, fmt.Errorf("holiday %q: %w", h.Date, err)
+		}
+		cal.holidays[h.Date] = h.Title
+	}
+
+	for _, w := range c.Workdays {
+		if _, err := time.Parse(constant.DateLayout, w.Date); err != nil {
+			return nil, fmt.Errorf("workday %q: %w", w.Date, err)
+		}
+
+		wd, ok := weekdays[strings.ToLower(w.As)]
+		if !ok {
+			return nil, fmt.Errorf("workday %q: unknown weekday %q", w.Date, w.As)
+		}
+		cal.workdays[w.Date] = wd
+	}
+
+	var err error
+	if cal.vacations, err = parsePeriods(c.Vacations, "каникулы"); err != nil {
+		return nil, fmt.Errorf("vacations: %w", err)
+	}
+	if cal.practice, err = parsePeriods(c.Practice, "практика"); err != nil {
+		return nil, fmt.Errorf("practice: %w", err)
+	}
+
+	return cal, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func parsePeriods(ps []Period, title string) ([]period, error) {
+	res := make([]period, 0, len(ps))
+
+	for _, p := range ps {
+		from, err := time.Parse(constant.DateLayout, p.From)
+		if err != nil {
+			return nil, fmt.Errorf("from %q: %w", p.From, err)
+		}
+
+		to, err := time.Parse(constant.DateLayout, p.To)
+		if err != nil {
+			return nil, fmt.Errorf("to %q: %w", p.To, err)
+		}
+
+		if to.Before(from) {
+			return nil, fmt.Errorf("%s ends before it starts", p.From)
+		}
+
+		pr := period{from: from, to: to, title: p.Title}
+		if pr.title == "" {
+			pr.title = title
+		}
+
+		if len(p.Groups) != 0 {
+			pr.groups = make(map[string]struct{}, len(p.Groups))
+			for _, g := range p.Groups {
+				pr.groups[g] = struct{}{}
+			}
+		}
+
+		res = append(res, pr)
+	}
+
+	return res, nil
+}
+
+// Day describes the date for the group. An empty group gets only the days off
+// common to all groups.
+func (c *Calendar) Day(group string, date time.Time) Day {
+	d := Day{Weekday: date.Weekday()}
+
+	if c == nil {
+		d.Study = d.Weekday != time.Saturday && d.Weekday != time.Sunday
+		return d
+	}
+
+	key := date.Format(constant.DateLayout)
+	// the periods are in dates, the time of the day must not matter
+	day, _ := time.Parse(constant.DateLayout, key)
+
+	if title, ok := c.holidays[key]; ok {
+		d.Reason = title
+		if d.Reason == "" {
+			d.Reason = "праздник"
+		}
+		return d
+	}
+
+	for _, ps := range [][]period{c.vacations, c.practice} {
+		for _, p := range ps {
+			if p.contains(group, day) {
+				d.Reason = p.title
+				return d
+			}
+		}
+	}
+
+	if wd, ok := c.workdays[key]; ok {
+		d.Study = true
+		d.Weekday = wd
+		return d
+	}
+
+	d.Study = d.Weekday != time.Saturday && d.Weekday != time.Sunday
+	return d
+}
+
+// WeekOff returns the days off of the group in the week of the date other
+// than the weekends, by their number from Monday.
+func (c *Calendar) WeekOff(group string, date time.Time) map[int]string {
+	monday := date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+
+	off := make(map[int]string)
+	for i := 0; i < 7; i++ {
+		if d := c.Day(group, monday.AddDate(0, 0, i)); !d.Study && d.Reason != "" {
+			off[i] = d.Off()
+		}
+	}
+
+	return off
+}
+
+// Open reports whether the date is a study day for some group, the jobs
+// sending to many groups run only on such days.
+func (c *Calendar) Open(date time.Time) bool {
+	return c.Day("", date).Study
+}
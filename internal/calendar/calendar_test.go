@@ -0,0 +1,75 @@
+package calendar
+
+import (
+	"bot/internal/constant"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Day(t *testing.T) {
+	cal, err := New(Config{
+		Holidays:  []Holiday{{Date: "2024-11-04", Title: "День народного единства"}},
+		Workdays:  []Workday{{Date: "2024-11-02", As: "monday"}},
+		Vacations: []Period{{From: "2024-12-30", To: "2025-01-08"}},
+		Practice:  []Period{{From: "2024-11-11", To: "2024-11-22", Groups: []string{"04 74-20"}}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	date := func(s string) time.Time {
+		d, _ := time.Parse(constant.DateLayout, s)
+		return d.Add(10 * time.Hour)
+	}
+
+	tests := []struct {
+		name  string
+		group string
+		date  string
+		want  Day
+	}{
+		{"weekday", "04 74-20", "2024-11-05", Day{Study: true, Weekday: time.Tuesday}},
+		{"weekend", "04 74-20", "2024-11-03", Day{Weekday: time.Sunday}},
+		{"holiday", "04 74-20", "2024-11-04", Day{Weekday: time.Monday, Reason: "День народного единства"}},
+		{"transferred", "04 74-20", "2024-11-02", Day{Study: true, Weekday: time.Monday}},
+		{"vacation", "04 74-20", "2025-01-08", Day{Weekday: time.Wednesday, Reason: "каникулы"}},
+		{"practice", "04 74-20", "2024-11-11", Day{Weekday: time.Monday, Reason: "практика"}},
+		{"practice of other group", "03 73-20", "2024-11-11", Day{Study: true, Weekday: time.Monday}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.Day(tt.group, date(tt.date)); got != tt.want {
+				t.Errorf("Day() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if !cal.Open(date("2024-11-11")) {
+		t.Errorf("Open() = false for a practice of one group")
+	}
+
+	off := cal.WeekOff("04 74-20", date("2024-11-06"))
+	if len(off) != 1 || off[0] != "выходной день (День народного единства)" {
+		t.Errorf("WeekOff() = %v", off)
+	}
+
+	var empty *Calendar
+	if empty.Open(date("2024-11-02")) || !empty.Open(date("2024-11-04")) {
+		t.Errorf("nil calendar must know only the weekends")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	configs := []Config{
+		{Holidays: []Holiday{{Date: "04.11"}}},
+		{Workdays: []Workday{{Date: "2024-11-02", As: "someday"}}},
+		{Vacations: []Period{{From: "2025-01-08", To: "2024-12-30"}}},
+	}
+
+	for _, c := range configs {
+		if _, err := New(c); err == nil {
+			t.Errorf("New(%+v) error = nil", c)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package constant
+
+// DateLayout is the layout of the dates in the config and in the storage.
+const DateLayout = "2006-01-02"
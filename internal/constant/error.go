@@ -3,8 +3,10 @@ package constant
 import "errors"
 
 var (
-	ErrGroupNotFound = errors.New("group not found")
-	ErrNoPair        = errors.New("no pair")
-	ErrUserNotFound  = errors.New("user not found")
-	ErrNoSubscribers = errors.New("no subscribers")
+	ErrGroupNotFound  = errors.New("group not found")
+	ErrNoPair         = errors.New("no pair")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrNoSubscribers  = errors.New("no subscribers")
+	ErrChatNotFound   = errors.New("chat not found")
+	ErrFollowNotFound = errors.New("follow not found")
 )
@@ -0,0 +1,36 @@
+package table
+
+import "time"
+
+// Broadcast is a message sent to many users at once by an admin or a headman.
+type Broadcast struct {
+	ID         int `gorm:"primary_key"`
+	AuthorID   int
+	Text       string
+	Total      int
+	Sent       int
+	Failed     int
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// BroadcastRecipient is the delivery outcome of the broadcast to one chat.
+type BroadcastRecipient struct {
+	ID          int `gorm:"primary_key"`
+	BroadcastID int `gorm:"index"`
+	UserID      int
+	ChatID      int64
+	Status      string
+	// Reason is the class of the failure, see broadcast.Classify.
+	Reason   string
+	Error    string
+	Attempts int
+	SentAt   time.Time
+}
+
+// Group of broadcast recipient statuses.
+const (
+	RecipientPending = "pending"
+	RecipientSent    = "sent"
+	RecipientFailed  = "failed"
+)
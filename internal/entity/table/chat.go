@@ -0,0 +1,11 @@
+package table
+
+// Chat is a group or a supergroup chat bound to a study group.
+type Chat struct {
+	ID         int64 `gorm:"primary_key"`
+	Title      string
+	Group      string
+	SubGroup   int
+	Subscribed bool
+	BoundBy    int
+}
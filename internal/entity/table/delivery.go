@@ -0,0 +1,29 @@
+package table
+
+import "time"
+
+// Delivery is a scheduled notification that was sent. The key columns are
+// unique, so each notification is sent at most once even across restarts.
+type Delivery struct {
+	ID     int    `gorm:"primary_key"`
+	UserID int    `gorm:"uniqueIndex:idx_delivery_key"`
+	ChatID int64  `gorm:"uniqueIndex:idx_delivery_key"`
+	Kind   string `gorm:"uniqueIndex:idx_delivery_key"`
+	// Ref is the follow or the event the notification is about.
+	Ref  int    `gorm:"uniqueIndex:idx_delivery_key"`
+	Date string `gorm:"uniqueIndex:idx_delivery_key;index"`
+	// Pair is the number of the pair starting from 1, zero for the whole day.
+	Pair   int `gorm:"uniqueIndex:idx_delivery_key"`
+	SentAt time.Time
+}
+
+// Group of delivery kinds.
+const (
+	DeliveryDaily   = "daily"
+	DeliveryDigest  = "digest"
+	DeliveryEvening = "evening"
+	DeliveryWeekly  = "weekly"
+	DeliveryPair    = "pair"
+	DeliveryEvent   = "event"
+	DeliveryChat    = "chat"
+)
@@ -0,0 +1,11 @@
+package table
+
+// Follow is a study group the user follows in addition to the primary one.
+type Follow struct {
+	ID       int `gorm:"primary_key"`
+	UserID   int `gorm:"index"`
+	Group    string
+	SubGroup int
+	Daily    bool
+	Pair     bool
+}
@@ -0,0 +1,13 @@
+package table
+
+// Homework is a homework entry posted by the headman for the whole group.
+type Homework struct {
+	ID    int    `gorm:"primary_key"`
+	Group string `gorm:"index"`
+	// Subject is the subject of the pair the homework is given for.
+	Subject string
+	// Due is the day the homework is due in the 2006-01-02 format.
+	Due      string `gorm:"index"`
+	Text     string
+	AuthorID int
+}
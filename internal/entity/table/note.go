@@ -0,0 +1,24 @@
+package table
+
+// Note is a personal note of the user attached to a pair of a date.
+type Note struct {
+	ID     int `gorm:"primary_key"`
+	UserID int `gorm:"index"`
+	// Date is the day of the pair in the 2006-01-02 format.
+	Date string `gorm:"index"`
+	// Pair is the number of the pair starting from 1.
+	Pair int
+	Text string
+}
+
+// Event is a personal event of the user, e.g. a consultation.
+type Event struct {
+	ID     int `gorm:"primary_key"`
+	UserID int `gorm:"index"`
+	// Date is the day of the event in the 2006-01-02 format.
+	Date string `gorm:"index"`
+	// Start is the start of the event in minutes since midnight.
+	Start int
+	Title string
+	Place string
+}
@@ -0,0 +1,14 @@
+package table
+
+// Notice is a notice pinned by the headman above the schedule of the group for
+// a range of days.
+type Notice struct {
+	ID    int    `gorm:"primary_key"`
+	Group string `gorm:"index"`
+	// FirstDay and LastDay are the days the notice is shown between in the
+	// 2006-01-02 format.
+	FirstDay string
+	LastDay  string `gorm:"index"`
+	Text     string
+	AuthorID int
+}
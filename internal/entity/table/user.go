@@ -8,9 +8,45 @@ type User struct {
 	ChatID         int64
 	Nickname       string
 	Admin          bool
+	Headman        bool
 	Group          string
 	SubGroup       int
 	Subscribed     bool
 	SubscribedPair bool
-	SilenceUntil   time.Time
+	// SubscribedEvening turns on the preview of tomorrow sent in the evening.
+	SubscribedEvening bool
+	// SubscribedWeekly turns on the digest of the coming week sent on Sunday
+	// evening.
+	SubscribedWeekly bool
+	SilenceUntil     time.Time
+	DailyHour        int `gorm:"default:8"`
+	DailyEvening     bool
+	RemindBefore     int `gorm:"default:10"`
+	RemindMode       int
+	QuietFrom        int
+	QuietTo          int
+	// Blocked is set when messages to the user fail because the user blocked
+	// the bot or deleted the account, it is reset when the user writes again.
+	Blocked bool
+	// TimeZone is the IANA name of the user's time zone, empty for the
+	// college's one. The times shown and the notifications follow it.
+	TimeZone string
 }
+
+// DefaultDailyHour is the hour the daily schedule is sent by default, it
+// matches the default of the DailyHour column.
+const DefaultDailyHour = 8
+
+// DefaultRemindBefore is the number of minutes a pair reminder is sent before
+// the pair by default, it matches the default of the RemindBefore column.
+const DefaultRemindBefore = 10
+
+// Group of pair reminder modes.
+const (
+	// RemindAll reminds about every pair.
+	RemindAll = iota
+	// RemindFirst reminds only about the first pair of the day.
+	RemindFirst
+	// RemindRoomChange reminds only when the room differs from the previous pair.
+	RemindRoomChange
+)
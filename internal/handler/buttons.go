@@ -11,6 +11,11 @@ var (
 		Data:   "-1",
 	}
 
+	weekButton = tb.InlineButton{
+		Unique: "week",
+		Text:   "🗓 Неделя",
+	}
+
 	settingsButton = tb.InlineButton{
 		Unique: "settings",
 		Text:   "⚙️ Настройки",
@@ -8,7 +8,7 @@ func (h *Handler) Start(c tb.Context) error {
 	repl := h.bot.NewMarkup()
 
 	repl.InlineKeyboard = [][]tb.InlineButton{
-		{scheduleButton},
+		{scheduleButton, weekButton},
 		{helpButton, settingsButton},
 	}
 
@@ -16,6 +16,7 @@ func (h *Handler) Start(c tb.Context) error {
 
 Возможности бота:
 - Просмотр расписания на текущую неделю.
+- Вся неделя одним сообщением: /week
 - Отправка расписания каждый день в 8:00.
 - Отправка коротких напомининий о новых парах на переменах.
 
@@ -60,6 +60,21 @@ func (h *Handler) Schedule(c tb.Context) error {
 	return c.Send(schedule)
 }
 
+func (h *Handler) Week(c tb.Context) error {
+	user := c.Sender()
+
+	if h.core.ValidateUser(int(user.ID)) != nil {
+		return h.Register(c)
+	}
+
+	week, err := h.core.GetWeek(int(user.ID))
+	if err != nil {
+		return c.Send("ошибка получения расписания")
+	}
+
+	return c.Send(week)
+}
+
 func (h *Handler) Settings(c tb.Context) error {
 	return nil
 }
@@ -117,9 +132,21 @@ func (h *Handler) HandlePlainText(c tb.Context) error {
 			log.Println(fmt.Sprintf("add group error: %v", err))
 			return err
 		}
+
+		return h.SuggestSubGroup(c)
+	}
+
+	date, ok := service.ParseDate(text, time.Now().In(service.Location()))
+	if !ok {
+		return c.Send(service.DateHint)
 	}
 
-	return h.SuggestSubGroup(c)
+	schedule, err := h.core.GetScheduleByDate(us.ID, date)
+	if err != nil {
+		return c.Send("ошибка получения расписания")
+	}
+
+	return c.Send(schedule)
 }
 
 func (h *Handler) SuggestSubGroup(c tb.Context) error {
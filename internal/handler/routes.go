@@ -4,8 +4,10 @@ import tb "gopkg.in/telebot.v3"
 
 func (h *Handler) register() {
 	h.bot.Handle("/start", h.Start)
+	h.bot.Handle("/week", h.Week)
 
 	h.bot.Handle(&scheduleButton, h.Schedule)
+	h.bot.Handle(&weekButton, h.Week)
 	h.bot.Handle(&settingsButton, h.Settings)
 	h.bot.Handle(&helpButton, h.Help)
 	h.bot.Handle(&toMainMenu, h.ToMainMenu)
@@ -0,0 +1,125 @@
+// Package scheduler runs the jobs of the bot at the times their triggers
+// tell, using a clock that can be replaced in tests.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// maxDelay is how late a job may run, the runs missed for longer, e.g. while
+// the machine was asleep, are skipped.
+const maxDelay = time.Minute
+
+// Job is a function run by the scheduler with the time it was due at.
+type Job func(ctx context.Context, at time.Time)
+
+type entry struct {
+	name    string
+	trigger Trigger
+	job     Job
+	next    time.Time
+}
+
+// Scheduler runs jobs one by one in the order they were added.
+type Scheduler struct {
+	clock  Clock
+	loc    *time.Location
+	logger *zap.Logger
+
+	entries []*entry
+}
+
+// New creates a new scheduler, the triggers get the time in the location.
+func New(clock Clock, loc *time.Location, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		clock:  clock,
+		loc:    loc,
+		logger: logger,
+	}
+}
+
+// Add adds the job, it must be called before Run.
+func (s *Scheduler) Add(name string, trigger Trigger, job Job) {
+	s.entries = append(s.entries, &entry{name: name, trigger: trigger, job: job})
+}
+
+// Run runs the jobs until the context is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	now := s.now()
+	for _, e := range s.entries {
+		e.next = e.trigger.Next(now)
+	}
+
+	for {
+		due, ok := s.earliest()
+		if !ok {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(due.Sub(s.clock.Now())):
+		}
+
+		now = s.now()
+
+		for _, e := range s.entries {
+			if e.next.IsZero() || e.next.After(now) {
+				continue
+			}
+
+			if now.Sub(e.next) > maxDelay {
+				s.logger.Warn(fmt.Sprintf("scheduler: job %s missed at %v", e.name, e.next))
+			} else {
+				s.run(ctx, e)
+			}
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			e.next = e.trigger.Next(maxTime(e.next, now))
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error(fmt.Sprintf("scheduler: job %s panic: %v", e.name, r))
+		}
+	}()
+
+	e.job(ctx, e.next)
+}
+
+// earliest returns the time the first job is due at.
+func (s *Scheduler) earliest() (time.Time, bool) {
+	var due time.Time
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			continue
+		}
+		if due.IsZero() || e.next.Before(due) {
+			due = e.next
+		}
+	}
+
+	return due, !due.IsZero()
+}
+
+func (s *Scheduler) now() time.Time {
+	return s.clock.Now().In(s.loc)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+var msk = time.FixedZone("MSK", 3*60*60)
+
+func TestOnDays(t *testing.T) {
+	// Friday
+	friday := time.Date(2024, time.September, 6, 23, 59, 0, 0, msk)
+
+	got := OnDays(Daily(8, 0), Weekdays).Next(friday)
+	want := time.Date(2024, time.September, 9, 8, 0, 0, 0, msk)
+	if !got.Equal(want) {
+		t.Errorf("OnDays().Next() = %v, want %v", got, want)
+	}
+
+	got = OnDays(Every(time.Minute), func(time.Time) bool { return false }).Next(friday)
+	if !got.IsZero() {
+		t.Errorf("OnDays().Next() = %v, want never", got)
+	}
+}
+
+func TestScheduler_SchoolDay(t *testing.T) {
+	// Monday
+	start := time.Date(2024, time.September, 2, 0, 0, 0, 0, msk)
+	clock := NewFake(start)
+
+	starts := []int{7*60 + 25, 9 * 60, 10*60 + 40, 12*60 + 30, 14*60 + 20, 16 * 60}
+	const remindBefore = 10
+
+	var daily, reminders []time.Time
+	minutes := 0
+
+	s := New(clock, msk, zap.NewNop())
+	s.Add("daily", OnDays(Daily(8, 0), Weekdays), func(_ context.Context, at time.Time) {
+		daily = append(daily, at)
+	})
+	s.Add("pairs", OnDays(Every(time.Minute), Weekdays), func(_ context.Context, at time.Time) {
+		minutes++
+		for _, m := range starts {
+			if at.Hour()*60+at.Minute() == m-remindBefore {
+				reminders = append(reminders, at)
+			}
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	// from Monday to the next Monday
+	for i := 0; i < 7*24*60; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+	}
+	clock.BlockUntil(1)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
+	}
+
+	if len(daily) != 5 {
+		t.Fatalf("daily runs = %v, want 5", daily)
+	}
+	for i, at := range daily {
+		want := start.AddDate(0, 0, i).Add(8 * time.Hour)
+		if !at.Equal(want) {
+			t.Errorf("daily run %d = %v, want %v", i, at, want)
+		}
+	}
+
+	if len(reminders) != 5*len(starts) {
+		t.Fatalf("reminders = %d, want %d", len(reminders), 5*len(starts))
+	}
+	for i, m := range starts {
+		want := start.Add(time.Duration(m-remindBefore) * time.Minute)
+		if !reminders[i].Equal(want) {
+			t.Errorf("reminder %d = %v, want %v", i, reminders[i], want)
+		}
+	}
+
+	// every minute of the five weekdays but the start, and the midnight of
+	// the next Monday
+	if want := 5 * 24 * 60; minutes != want {
+		t.Errorf("pair ticks = %d, want %d", minutes, want)
+	}
+}
+
+func TestScheduler_SkipsMissed(t *testing.T) {
+	start := time.Date(2024, time.September, 2, 7, 59, 0, 0, msk)
+	clock := NewFake(start)
+
+	runs := 0
+	s := New(clock, msk, zap.NewNop())
+	s.Add("daily", Daily(8, 0), func(context.Context, time.Time) { runs++ })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	// the machine slept through the run
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	clock.BlockUntil(1)
+
+	cancel()
+	<-done
+
+	if runs != 0 {
+		t.Errorf("runs = %d, want 0", runs)
+	}
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// maxSearchDays is how far a trigger looks for the next day allowed by its
+// calendar before giving up.
+const maxSearchDays = 366
+
+// Trigger decides when a job runs.
+type Trigger interface {
+	// Next returns the first run time after t, the zero time means never.
+	Next(t time.Time) time.Time
+}
+
+// TriggerFunc is a function used as a trigger.
+type TriggerFunc func(t time.Time) time.Time
+
+func (f TriggerFunc) Next(t time.Time) time.Time {
+	return f(t)
+}
+
+// Every runs the job at the multiples of the interval since midnight, e.g.
+// every minute at zero seconds.
+func Every(d time.Duration) Trigger {
+	return TriggerFunc(func(t time.Time) time.Time {
+		midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		since := t.Sub(midnight)
+
+		return midnight.Add((since/d + 1) * d)
+	})
+}
+
+// Daily runs the job every day at the time.
+func Daily(hour, minute int) Trigger {
+	return TriggerFunc(func(t time.Time) time.Time {
+		next := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location())
+		if !next.After(t) {
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, hour, minute, 0, 0, t.Location())
+		}
+
+		return next
+	})
+}
+
+// OnDays runs the job by the trigger only on the days the calendar allows,
+// e.g. on study days.
+func OnDays(trigger Trigger, allowed func(day time.Time) bool) Trigger {
+	return TriggerFunc(func(t time.Time) time.Time {
+		next := trigger.Next(t)
+
+		for i := 0; i < maxSearchDays && !next.IsZero(); i++ {
+			if allowed(next) {
+				return next
+			}
+
+			// skip to the end of the day that is not allowed
+			midnight := time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			next = trigger.Next(midnight.Add(-time.Nanosecond))
+		}
+
+		return time.Time{}
+	})
+}
+
+// Weekdays is the calendar of the days from Monday to Friday.
+func Weekdays(day time.Time) bool {
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
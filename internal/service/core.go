@@ -1,22 +1,26 @@
 package service
 
 import (
+	"bot/internal/calendar"
 	"bot/internal/constant"
 	"bot/internal/entity/table"
 	"bot/internal/storage"
 	"errors"
+	"fmt"
 	"log"
 	"time"
 )
 
 type Core struct {
 	schedule *ScheduleService
-	storage  *storage.Storage
+	calendar *calendar.Calendar
+	storage  storage.Repository
 }
 
-func NewCore(schedule *ScheduleService, storage *storage.Storage) *Core {
+func NewCore(schedule *ScheduleService, calendar *calendar.Calendar, storage storage.Repository) *Core {
 	return &Core{
 		schedule: schedule,
+		calendar: calendar,
 		storage:  storage,
 	}
 }
@@ -40,7 +44,60 @@ func (c Core) GetSchedule(userID int, offset int) (sc string, err error) {
 		return "", err
 	}
 
-	return DayToString(day, offset == -1, offset, user.SubGroup), nil
+	return DayToString(day, offset == -1, offset, user.SubGroup, nil), nil
+}
+
+// GetScheduleByDate returns the schedule of the user's group for the date.
+func (c Core) GetScheduleByDate(userID int, date time.Time) (string, error) {
+	user, err := c.storage.GetUserByID(userID)
+	if err != nil {
+		log.Println("get user error: ", err)
+		return "", err
+	}
+
+	cd := c.calendar.Day(user.Group, date)
+	if !cd.Study {
+		return fmt.Sprintf("%s %s — %s, пар нет.", toDay(int(date.Weekday()+6)%7), date.Format("02.01"), cd.Off()), nil
+	}
+
+	offset := weekdayToInt(cd.Weekday)
+
+	day, err := c.schedule.GetDayByGroup(user.Group, offset)
+	if err != nil {
+		log.Println("get day error: ", err)
+		return "", err
+	}
+
+	notes, err := c.storage.GetNotes(user.ID, date.Format(constant.DateLayout))
+	if err != nil {
+		log.Println("get notes error: ", err)
+	}
+
+	homework, err := c.storage.GetHomework(user.Group, date.Format(constant.DateLayout))
+	if err != nil {
+		log.Println("get homework error: ", err)
+	}
+
+	lines := HomeworkByPair(NotesByPair(notes), day, homework, user.SubGroup)
+
+	return date.Format("02.01") + "\n" + DayToString(day, false, offset, user.SubGroup, lines), nil
+}
+
+func (c Core) GetWeek(userID int) (string, error) {
+	user, err := c.storage.GetUserByID(userID)
+	if err != nil {
+		log.Println("get user error: ", err)
+		return "", err
+	}
+
+	week, err := c.schedule.GetWeekByGroup(user.Group)
+	if err != nil {
+		log.Println("get week error: ", err)
+		return "", err
+	}
+
+	now := time.Now().In(Location())
+	return WeekToString(week, WeekIndex(now), user.SubGroup, c.calendar.WeekOff(user.Group, now)), nil
 }
 
 func weekdayToInt(w time.Weekday) int {
@@ -105,6 +162,10 @@ func (c Core) AddGroup(us table.User, g string) error {
 		return constant.ErrGroupNotFound
 	}
 
+	// the headman role is given for the group, it is lost along with it
+	if us.Group != g {
+		us.Headman = false
+	}
 	us.Group = g
 
 	if err := c.storage.SaveUser(us); err != nil {
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"bot/internal/entity/table"
+	"bot/internal/storage"
+	"testing"
+)
+
+func TestCore_AddGroup_Headman(t *testing.T) {
+	repo := storage.NewMemory()
+	core := NewCore(&ScheduleService{schedule: map[group]WorkWeek{
+		"ИС-21": nil,
+		"ИС-22": nil,
+	}}, nil, repo)
+
+	us := table.User{ID: 1, ChatID: 1, Group: "ИС-21", Headman: true}
+	if err := repo.AddUser(us); err != nil {
+		t.Fatalf("AddUser() error = %v", err)
+	}
+
+	if err := core.AddGroup(us, "ИС-21"); err != nil {
+		t.Fatalf("AddGroup() error = %v", err)
+	}
+	if got, _ := repo.GetUserByID(1); !got.Headman {
+		t.Errorf("headman role lost after choosing the same group")
+	}
+
+	if err := core.AddGroup(us, "ИС-22"); err != nil {
+		t.Fatalf("AddGroup() error = %v", err)
+	}
+	got, err := repo.GetUserByID(1)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if got.Group != "ИС-22" || got.Headman {
+		t.Errorf("AddGroup() saved group %q, headman %v, want ИС-22, false", got.Group, got.Headman)
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DateHint is the answer to a date expression ParseDate doesn't understand.
+const DateHint = "Не понял дату 🤔 Напиши, например, «завтра», «в пятницу», «следующий понедельник» или «25.10»."
+
+var (
+	locOnce sync.Once
+	loc     *time.Location
+)
+
+// Location returns the time zone of the college, the relative dates are
+// resolved in it. UTC+3 is used if the zone data is missing.
+func Location() *time.Location {
+	locOnce.Do(func() {
+		var err error
+		if loc, err = time.LoadLocation("Europe/Moscow"); err != nil {
+			loc = time.FixedZone("MSK", 3*60*60)
+		}
+	})
+
+	return loc
+}
+
+// weekdays maps Russian weekday names and their short forms to time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"понедельник": time.Monday,
+	"пн":          time.Monday,
+	"вторник":     time.Tuesday,
+	"вт":          time.Tuesday,
+	"среда":       time.Wednesday,
+	"среду":       time.Wednesday,
+	"ср":          time.Wednesday,
+	"четверг":     time.Thursday,
+	"чт":          time.Thursday,
+	"пятница":     time.Friday,
+	"пятницу":     time.Friday,
+	"пт":          time.Friday,
+	"суббота":     time.Saturday,
+	"субботу":     time.Saturday,
+	"сб":          time.Saturday,
+	"воскресенье": time.Sunday,
+	"вс":          time.Sunday,
+}
+
+// fillers are words that carry no meaning for the date expression.
+var fillers = map[string]struct{}{
+	"на":         {},
+	"в":          {},
+	"во":         {},
+	"расписание": {},
+	"пары":       {},
+}
+
+// WeekIndex returns the number of the day of the date in its week from Monday,
+// the days of a WorkWeek are numbered the same way.
+func WeekIndex(date time.Time) int {
+	return (int(date.Weekday()) + 6) % 7
+}
+
+// ParseDate parses a Russian date expression relative to now: "сегодня",
+// "завтра", "послезавтра", weekday names ("в пятницу", "следующий
+// понедельник") and dates in the DD.MM or DD.MM.YYYY form. The returned time
+// is the midnight of the resolved day in now's location.
+func ParseDate(text string, now time.Time) (time.Time, bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.ReplaceAll(text, "ё", "е")
+	text = strings.Trim(text, "?!,")
+
+	var words []string
+	for _, w := range strings.Fields(text) {
+		if _, ok := fillers[w]; ok {
+			continue
+		}
+		words = append(words, w)
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	next := false
+	if len(words) == 2 && strings.HasPrefix(words[0], "след") {
+		next = true
+		words = words[1:]
+	}
+
+	if len(words) != 1 {
+		return time.Time{}, false
+	}
+
+	word := words[0]
+
+	if !next {
+		switch word {
+		case "сегодня":
+			return midnight, true
+		case "завтра":
+			return midnight.AddDate(0, 0, 1), true
+		case "послезавтра":
+			return midnight.AddDate(0, 0, 2), true
+		}
+	}
+
+	if wd, ok := weekdays[word]; ok {
+		if next {
+			// the same weekday of the next week
+			monday := midnight.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+			return monday.AddDate(0, 0, 7+(int(wd)+6)%7), true
+		}
+
+		return midnight.AddDate(0, 0, (int(wd)-int(now.Weekday())+7)%7), true
+	}
+
+	if next {
+		return time.Time{}, false
+	}
+
+	return parseNumericDate(word, now)
+}
+
+// parseNumericDate parses DD.MM and DD.MM.YYYY dates. Dates without a year are
+// taken in the current year.
+func parseNumericDate(word string, now time.Time) (time.Time, bool) {
+	split := strings.Split(strings.TrimSuffix(word, "."), ".")
+	if len(split) != 2 && len(split) != 3 {
+		return time.Time{}, false
+	}
+
+	day, err := strconv.Atoi(split[0])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	month, err := strconv.Atoi(split[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	year := now.Year()
+	if len(split) == 3 {
+		if year, err = strconv.Atoi(split[2]); err != nil {
+			return time.Time{}, false
+		}
+		if year < 100 {
+			year += 2000
+		}
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location())
+	if date.Day() != day || int(date.Month()) != month {
+		return time.Time{}, false
+	}
+
+	return date, true
+}
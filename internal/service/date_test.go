@@ -0,0 +1,112 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	// Wednesday
+	now := time.Date(2023, time.October, 18, 14, 30, 0, 0, time.UTC)
+
+	type args struct {
+		text string
+	}
+	tests := []struct {
+		name   string
+		args   args
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			name:   "today",
+			args:   args{text: "Сегодня"},
+			want:   time.Date(2023, time.October, 18, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "tomorrow with filler",
+			args:   args{text: "расписание на завтра"},
+			want:   time.Date(2023, time.October, 19, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "day after tomorrow",
+			args:   args{text: "послезавтра"},
+			want:   time.Date(2023, time.October, 20, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "weekday later this week",
+			args:   args{text: "в пятницу"},
+			want:   time.Date(2023, time.October, 20, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "weekday is today",
+			args:   args{text: "среда"},
+			want:   time.Date(2023, time.October, 18, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "weekday already passed",
+			args:   args{text: "во вторник"},
+			want:   time.Date(2023, time.October, 24, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "next monday",
+			args:   args{text: "следующий понедельник"},
+			want:   time.Date(2023, time.October, 23, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "next friday",
+			args:   args{text: "в следующую пятницу"},
+			want:   time.Date(2023, time.October, 27, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "short weekday",
+			args:   args{text: "пн"},
+			want:   time.Date(2023, time.October, 23, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "date",
+			args:   args{text: "25.10"},
+			want:   time.Date(2023, time.October, 25, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "date with year",
+			args:   args{text: "01.02.24"},
+			want:   time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name: "invalid date",
+			args: args{text: "31.02"},
+		},
+		{
+			name: "group name",
+			args: args{text: "04 74-20"},
+		},
+		{
+			name: "next without weekday",
+			args: args{text: "следующий завтра"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseDate(tt.args.text, now)
+			if ok != tt.wantOk {
+				t.Errorf("ParseDate() ok = %v, wantOk %v", ok, tt.wantOk)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDate() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,18 +3,28 @@ package service
 import (
 	"bot/config"
 	"bot/internal/constant"
+	"bot/internal/entity/table"
 	"bot/internal/storage"
 	"fmt"
 	"github.com/xuri/excelize/v2"
+	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 type ScheduleService struct {
 	paths         []string
 	maxPairPerDay int
-	storage       *storage.Storage
-	schedule      map[group]WorkWeek // todo: add lock
+	storage       storage.UserRepository
+
+	mu        sync.RWMutex
+	schedule  map[group]WorkWeek
+	campuses  map[group]string
+	warnings  []string
+	updatedAt time.Time
 }
 
 type group string
@@ -79,7 +89,42 @@ func findGroup(pe PairEntity, groupID int) (Pair, error) {
 	return Pair{}, constant.ErrGroupNotFound
 }
 
-func DayToString(day WorkDay, needNew bool, offset int, subGroup int) string {
+// NotesByPair groups the notes by the pair index starting from 0, the lines
+// are ready to be shown under the pairs.
+func NotesByPair(notes []table.Note) map[int][]string {
+	m := make(map[int][]string, len(notes))
+	for _, n := range notes {
+		m[n.Pair-1] = append(m[n.Pair-1], "📝 "+n.Text)
+	}
+
+	return m
+}
+
+// HomeworkByPair adds the homework to the lines shown under the pairs of the
+// day, each entry goes under the first pair of the subgroup with its subject.
+func HomeworkByPair(m map[int][]string, day WorkDay, homework []table.Homework, subGroup int) map[int][]string {
+	if m == nil {
+		m = make(map[int][]string, len(homework))
+	}
+
+	for _, hw := range homework {
+		for i, pairE := range day {
+			p, err := findGroup(pairE, subGroup)
+			if err != nil || p.Subject != hw.Subject {
+				continue
+			}
+
+			m[i] = append(m[i], "📚 "+hw.Text)
+			break
+		}
+	}
+
+	return m
+}
+
+// DayToString renders the day for the subgroup, notes are the lines shown
+// under the pairs by their indexes.
+func DayToString(day WorkDay, needNew bool, offset int, subGroup int, notes map[int][]string) string {
 	var sb strings.Builder
 	if len(day) > 0 {
 		if needNew {
@@ -94,9 +139,21 @@ func DayToString(day WorkDay, needNew bool, offset int, subGroup int) string {
 		return sb.String()
 	}
 
+	return sb.String() + pairsToString(day, subGroup, notes, false)
+}
+
+// pairsToString renders the pairs of the day for the subgroup, notes are the
+// lines shown under the pairs by their indexes. compact skips the empty slots
+// and the pairs of the other subgroup.
+func pairsToString(day WorkDay, subGroup int, notes map[int][]string, compact bool) string {
+	var sb strings.Builder
 	for i, pairE := range day {
 		actualPair, err := findGroup(pairE, subGroup)
 		if err != nil {
+			if compact {
+				continue
+			}
+
 			switch err {
 			case constant.ErrGroupNotFound:
 				sb.WriteString(
@@ -107,17 +164,50 @@ func DayToString(day WorkDay, needNew bool, offset int, subGroup int) string {
 			case constant.ErrNoPair:
 				sb.WriteString(
 					fmt.Sprintf(
-						"№%d\nПара не найдена, проверьте на сайте на всякий случай)\n\n", i+1,
+						"№%d\nПара не найдена, проверьте на сайте на всякий случай)\n\n", i+1,
 					),
 				)
 			}
 		} else {
 			sb.WriteString(
 				fmt.Sprintf(
-					"№%d\nПредмет: %s\nКабинет: %s\nПреподаватель: %s\n\n",
+					"№%d\nПредмет: %s\nКабинет: %s\nПреподаватель: %s\n",
 					i+1, actualPair.Subject, actualPair.Room, actualPair.Teacher,
 				),
 			)
+			for _, note := range notes[i] {
+				sb.WriteString(note + "\n")
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// WeekToString renders the whole week, the days are rendered like in
+// DayToString without the empty slots and the pairs of the other subgroup.
+// today is marked with 📍.
+func WeekToString(week WorkWeek, today int, subGroup int, off map[int]string) string {
+	var sb strings.Builder
+	sb.WriteString("Расписание на неделю:\n")
+
+	for i, day := range week {
+		if i == today {
+			sb.WriteString(fmt.Sprintf("\n📍 %s (сегодня)\n", toDay(i)))
+		} else {
+			sb.WriteString(fmt.Sprintf("\n%s\n", toDay(i)))
+		}
+
+		if text, ok := off[i]; ok {
+			sb.WriteString("Пар нет: " + text + "\n")
+			continue
+		}
+
+		if text := pairsToString(day, subGroup, nil, true); text != "" {
+			sb.WriteString(text)
+		} else {
+			sb.WriteString("Нет пар\n")
 		}
 	}
 
@@ -132,10 +222,15 @@ func NewSchedule(c config.Config) (*ScheduleService, error) {
 	}, nil
 }
 
+// Update parses the schedule files and replaces the schedule. Pairs that
+// can't be parsed are skipped and reported as warnings.
 func (s *ScheduleService) Update() (err error) {
 	var allCols [][]string
+	campuses := make(map[group]string)
 
 	for _, path := range s.paths {
+		campus := campusName(path)
+
 		err = func() error {
 			f, err := excelize.OpenFile(path)
 			if err != nil {
@@ -155,6 +250,12 @@ func (s *ScheduleService) Update() (err error) {
 					return fmt.Errorf("get cols: %w", err)
 				}
 
+				for _, col := range cols {
+					if len(col) > 0 && col[0] != "" {
+						campuses[group(col[0])] = campus
+					}
+				}
+
 				allCols = append(allCols, cols...)
 			}
 
@@ -170,26 +271,125 @@ func (s *ScheduleService) Update() (err error) {
 	delete(m, "День\nнеде")
 	delete(m, "Время")
 
+	var warnings []string
 	var all = make(map[group]WorkWeek, len(m))
 	for group, week := range m {
+		empty := true
 		all[group] = make(WorkWeek, len(week))
 		for i, day := range week {
 			all[group][i] = make(WorkDay, len(day))
 			for j, pair := range day {
 				all[group][i][j], err = newFromKabAndPair(pair)
 				if err != nil {
-					return fmt.Errorf("newFromKabAndPair: %w", err)
+					warnings = append(warnings, fmt.Sprintf("%s, %s, пара №%d: %v", group, toDay(i), j+1, err))
+					continue
 				}
+				empty = false
 			}
 		}
+
+		if empty {
+			warnings = append(warnings, fmt.Sprintf("%s: нет пар", group))
+		}
 	}
 
+	sort.Strings(warnings)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.schedule = all
+	s.campuses = campuses
+	s.warnings = warnings
+	s.updatedAt = time.Now()
 
 	return nil
 }
 
+// Reload updates the schedule and returns the groups whose schedule changed.
+func (s *ScheduleService) Reload() ([]string, error) {
+	s.mu.RLock()
+	old := s.schedule
+	s.mu.RUnlock()
+
+	if err := s.Update(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return changedGroups(old, s.schedule), nil
+}
+
+// changedGroups returns the sorted groups of both schedules whose weeks
+// differ, the added and the removed groups aren't reported.
+func changedGroups(old, updated map[group]WorkWeek) []string {
+	var changed []string
+	for g, w := range updated {
+		if prev, ok := old[g]; ok && !reflect.DeepEqual(prev, w) {
+			changed = append(changed, string(g))
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+// campusName returns the campus of the schedule file, it is the name of the
+// file without the extension.
+func campusName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// UpdatedAt returns the time the schedule was last updated.
+func (s *ScheduleService) UpdatedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.updatedAt
+}
+
+// Warnings returns the problems found during the last update.
+func (s *ScheduleService) Warnings() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.warnings
+}
+
+// Campus returns the campus the group studies at.
+func (s *ScheduleService) Campus(groupName string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.campuses[group(groupName)]
+}
+
+// Campuses returns the names of all campuses.
+func (s *ScheduleService) Campuses() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]struct{})
+	var names []string
+	for _, c := range s.campuses {
+		if _, ok := seen[c]; !ok {
+			seen[c] = struct{}{}
+			names = append(names, c)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
 func (s *ScheduleService) GetWeekByGroup(groupName string) (WorkWeek, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	if s.schedule == nil {
 		return nil, fmt.Errorf("no schedule")
 	}
@@ -202,10 +402,6 @@ func (s *ScheduleService) GetWeekByGroup(groupName string) (WorkWeek, error) {
 }
 
 func (s *ScheduleService) GetDayByGroup(groupName string, offset int) (WorkDay, error) {
-	if s.schedule == nil {
-		return nil, fmt.Errorf("no schedule")
-	}
-
 	w, err := s.GetWeekByGroup(groupName)
 	if err != nil {
 		return nil, err
@@ -219,6 +415,9 @@ func (s *ScheduleService) GetDayByGroup(groupName string, offset int) (WorkDay,
 }
 
 func (s *ScheduleService) GetDayGroupNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var names []string
 	for g := range s.schedule {
 		names = append(names, string(g))
@@ -228,6 +427,9 @@ func (s *ScheduleService) GetDayGroupNames() []string {
 }
 
 func (s *ScheduleService) VerifyGroup(g string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	if s.schedule == nil {
 		return false
 	}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bot/internal/entity/table"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWeekIndex(t *testing.T) {
+	// Monday
+	monday := time.Date(2023, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 7; i++ {
+		if got := WeekIndex(monday.AddDate(0, 0, i)); got != i {
+			t.Errorf("WeekIndex(%v) = %d, want %d", monday.AddDate(0, 0, i).Weekday(), got, i)
+		}
+	}
+}
+
+func TestWeekToString(t *testing.T) {
+	week := WorkWeek{
+		{
+			{{Subject: "Математика", Room: "101", Teacher: "Иванов"}},
+			nil,
+			{{Subject: "Физика", Room: "202", Teacher: "Петров", Group: 1}, {Subject: "Химия", Room: "303", Teacher: "Сидоров", Group: 2}},
+		},
+		{
+			{{Subject: "История", Room: "404", Teacher: "Смирнов", Group: 2}},
+		},
+		{
+			{{Subject: "Литература", Room: "505", Teacher: "Кузнецова"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		today    int
+		subGroup int
+		off      map[int]string
+		want     string
+	}{
+		{
+			name:     "first subgroup",
+			today:    1,
+			subGroup: 1,
+			want: "Расписание на неделю:\n" +
+				"\nПонедельник\n" +
+				"№1\nПредмет: Математика\nКабинет: 101\nПреподаватель: Иванов\n\n" +
+				"№3\nПредмет: Физика\nКабинет: 202\nПреподаватель: Петров\n\n" +
+				"\n📍 Вторник (сегодня)\nНет пар\n" +
+				"\nСреда\n" +
+				"№1\nПредмет: Литература\nКабинет: 505\nПреподаватель: Кузнецова\n\n",
+		},
+		{
+			name:     "second subgroup with a day off",
+			today:    -1,
+			subGroup: 2,
+			off:      map[int]string{2: "выходной день (День народного единства)"},
+			want: "Расписание на неделю:\n" +
+				"\nПонедельник\n" +
+				"№1\nПредмет: Математика\nКабинет: 101\nПреподаватель: Иванов\n\n" +
+				"№3\nПредмет: Химия\nКабинет: 303\nПреподаватель: Сидоров\n\n" +
+				"\nВторник\n" +
+				"№1\nПредмет: История\nКабинет: 404\nПреподаватель: Смирнов\n\n" +
+				"\nСреда\nПар нет: выходной день (День народного единства)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WeekToString(week, tt.today, tt.subGroup, tt.off)
+			if got != tt.want {
+				t.Errorf("WeekToString() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHomeworkByPair(t *testing.T) {
+	day := WorkDay{
+		{{Subject: "Математика", Room: "101"}},
+		{{Subject: "Физика", Room: "202", Group: 1}, {Subject: "Химия", Room: "303", Group: 2}},
+		{{Subject: "Математика", Room: "101"}},
+		{{Subject: "Химия", Room: "303", Group: 1}},
+	}
+
+	tests := []struct {
+		name     string
+		homework []table.Homework
+		subGroup int
+		want     map[int][]string
+	}{
+		{
+			name:     "repeated pair",
+			homework: []table.Homework{{Subject: "Математика", Text: "№1-5"}},
+			subGroup: 1,
+			want:     map[int][]string{0: {"📚 №1-5"}},
+		},
+		{
+			name:     "own subgroup pair",
+			homework: []table.Homework{{Subject: "Химия", Text: "реферат"}},
+			subGroup: 2,
+			want:     map[int][]string{1: {"📚 реферат"}},
+		},
+		{
+			name:     "other subgroup pair skipped",
+			homework: []table.Homework{{Subject: "Химия", Text: "реферат"}},
+			subGroup: 1,
+			want:     map[int][]string{3: {"📚 реферат"}},
+		},
+		{
+			name:     "no pair of the subject",
+			homework: []table.Homework{{Subject: "История", Text: "параграф 3"}},
+			subGroup: 1,
+			want:     map[int][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HomeworkByPair(nil, day, tt.homework, tt.subGroup)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HomeworkByPair() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangedGroups(t *testing.T) {
+	week := func(subject, room string) WorkWeek {
+		return WorkWeek{{{{Subject: subject, Room: room}}}}
+	}
+
+	old := map[group]WorkWeek{
+		"ИС-21": week("Математика", "101"),
+		"ИС-22": week("Физика", "202"),
+		"ТО-11": week("Химия", "303"),
+		"ТО-12": week("История", "404"),
+	}
+	updated := map[group]WorkWeek{
+		"ИС-21": week("Математика", "101"),
+		"ИС-22": week("Физика", "205"),
+		"ТО-11": week("Литература", "303"),
+		"ТО-13": week("История", "404"),
+	}
+
+	got := changedGroups(old, updated)
+	want := []string{"ИС-22", "ТО-11"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("changedGroups() = %v, want %v", got, want)
+	}
+
+	if got := changedGroups(nil, updated); len(got) != 0 {
+		t.Errorf("changedGroups() of the first load = %v, want none", got)
+	}
+}
@@ -0,0 +1,751 @@
+package storage
+
+import (
+	"bot/internal/constant"
+	"bot/internal/entity/table"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+)
+
+// Memory is a thread-safe storage kept in memory. It behaves like Storage,
+// including the errors and the order of the returned rows, and is meant for
+// tests and local runs.
+type Memory struct {
+	mu sync.RWMutex
+
+	users      map[int]table.User
+	chats      map[int64]table.Chat
+	follows    map[int]table.Follow
+	notes      map[int]table.Note
+	events     map[int]table.Event
+	homework   map[int]table.Homework
+	notices    map[int]table.Notice
+	broadcasts map[int]table.Broadcast
+	recipients map[int]table.BroadcastRecipient
+	deliveries map[int]table.Delivery
+
+	// seq is the last ID of each table like the autoincrement of a database.
+	seq map[string]int
+}
+
+// NewMemory returns a new empty in-memory storage.
+func NewMemory() *Memory {
+	return &Memory{
+		users:      make(map[int]table.User),
+		chats:      make(map[int64]table.Chat),
+		follows:    make(map[int]table.Follow),
+		notes:      make(map[int]table.Note),
+		events:     make(map[int]table.Event),
+		homework:   make(map[int]table.Homework),
+		notices:    make(map[int]table.Notice),
+		broadcasts: make(map[int]table.Broadcast),
+		recipients: make(map[int]table.BroadcastRecipient),
+		deliveries: make(map[int]table.Delivery),
+		seq:        make(map[string]int),
+	}
+}
+
+// id returns the ID of a new row of the table, the given one if it is set.
+func (m *Memory) id(name string, ID int) int {
+	if ID == 0 {
+		ID = m.seq[name] + 1
+	}
+
+	if ID > m.seq[name] {
+		m.seq[name] = ID
+	}
+
+	return ID
+}
+
+// withUserDefaults sets the column defaults of the new user.
+func withUserDefaults(us table.User) table.User {
+	if us.DailyHour == 0 {
+		us.DailyHour = table.DefaultDailyHour
+	}
+	if us.RemindBefore == 0 {
+		us.RemindBefore = table.DefaultRemindBefore
+	}
+
+	return us
+}
+
+func (m *Memory) GetUserByID(ID int) (table.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[ID]
+	if !ok {
+		return table.User{}, constant.ErrUserNotFound
+	}
+
+	return u, nil
+}
+
+// firstUser returns the user with the lowest ID matching the condition.
+func (m *Memory) firstUser(match func(u table.User) bool) (table.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.sortedUsers() {
+		if match(u) {
+			return u, nil
+		}
+	}
+
+	return table.User{}, constant.ErrUserNotFound
+}
+
+func (m *Memory) GetUserByChatID(chatID int64) (table.User, error) {
+	return m.firstUser(func(u table.User) bool { return u.ChatID == chatID })
+}
+
+func (m *Memory) GetUserByNickname(nickname string) (table.User, error) {
+	return m.firstUser(func(u table.User) bool { return u.Nickname == nickname })
+}
+
+func (m *Memory) AddUser(us table.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[us.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	us.ID = m.id("users", us.ID)
+	m.users[us.ID] = withUserDefaults(us)
+	return nil
+}
+
+func (m *Memory) SaveUser(us table.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[us.ID]; !ok {
+		us = withUserDefaults(us)
+	}
+
+	us.ID = m.id("users", us.ID)
+	m.users[us.ID] = us
+	return nil
+}
+
+// DeleteUser deletes the user along with the user's follows, notes and events.
+func (m *Memory) DeleteUser(ID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, f := range m.follows {
+		if f.UserID == ID {
+			delete(m.follows, id)
+		}
+	}
+	for id, n := range m.notes {
+		if n.UserID == ID {
+			delete(m.notes, id)
+		}
+	}
+	for id, e := range m.events {
+		if e.UserID == ID {
+			delete(m.events, id)
+		}
+	}
+
+	delete(m.users, ID)
+	return nil
+}
+
+// Reactivate resets the blocked flag of the user, it reports whether the user
+// was blocked.
+func (m *Memory) Reactivate(userID int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || !u.Blocked {
+		return false, nil
+	}
+
+	u.Blocked = false
+	m.users[userID] = u
+	return true, nil
+}
+
+func (m *Memory) GetSubscribers() ([]table.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var subs []table.User
+	for _, u := range m.sortedUsers() {
+		if u.Subscribed && !u.Blocked {
+			subs = append(subs, u)
+		}
+	}
+
+	if len(subs) == 0 {
+		return subs, constant.ErrNoSubscribers
+	}
+
+	return subs, nil
+}
+
+// GetUsers returns all users.
+func (m *Memory) GetUsers() ([]table.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sortedUsers(), nil
+}
+
+// GetUsersByGroup returns the users of the group who didn't block the bot.
+func (m *Memory) GetUsersByGroup(group string) ([]table.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var users []table.User
+	for _, u := range m.sortedUsers() {
+		if u.Group == group && !u.Blocked {
+			users = append(users, u)
+		}
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		if users[i].SubGroup != users[j].SubGroup {
+			return users[i].SubGroup < users[j].SubGroup
+		}
+		return users[i].Name < users[j].Name
+	})
+
+	return users, nil
+}
+
+// sortedUsers returns the users ordered by ID, m.mu must be held.
+func (m *Memory) sortedUsers() []table.User {
+	users := make([]table.User, 0, len(m.users))
+	for _, u := range m.users {
+		users = append(users, u)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+func (m *Memory) GetChatByID(ID int64) (table.Chat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.chats[ID]
+	if !ok {
+		return table.Chat{}, constant.ErrChatNotFound
+	}
+
+	return c, nil
+}
+
+func (m *Memory) SaveChat(c table.Chat) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.chats[c.ID] = c
+	return nil
+}
+
+func (m *Memory) DeleteChat(ID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.chats, ID)
+	return nil
+}
+
+func (m *Memory) GetSubscribedChats() ([]table.Chat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var chats []table.Chat
+	for _, c := range m.chats {
+		if c.Subscribed {
+			chats = append(chats, c)
+		}
+	}
+
+	sort.Slice(chats, func(i, j int) bool { return chats[i].ID < chats[j].ID })
+	return chats, nil
+}
+
+func (m *Memory) GetFollows(userID int) ([]table.Follow, error) {
+	return m.findFollows(func(f table.Follow) bool { return f.UserID == userID }), nil
+}
+
+func (m *Memory) GetFollowByID(ID int) (table.Follow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.follows[ID]
+	if !ok {
+		return table.Follow{}, constant.ErrFollowNotFound
+	}
+
+	return f, nil
+}
+
+// GetSubscribedFollows returns the follows with the daily schedule or the pair
+// reminders turned on.
+func (m *Memory) GetSubscribedFollows() ([]table.Follow, error) {
+	return m.findFollows(func(f table.Follow) bool { return f.Daily || f.Pair }), nil
+}
+
+// findFollows returns the follows matching the condition ordered by ID.
+func (m *Memory) findFollows(match func(f table.Follow) bool) []table.Follow {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var follows []table.Follow
+	for _, f := range m.follows {
+		if match(f) {
+			follows = append(follows, f)
+		}
+	}
+
+	sort.Slice(follows, func(i, j int) bool { return follows[i].ID < follows[j].ID })
+	return follows
+}
+
+func (m *Memory) AddFollow(f table.Follow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.follows[f.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	f.ID = m.id("follows", f.ID)
+	m.follows[f.ID] = f
+	return nil
+}
+
+func (m *Memory) SaveFollow(f table.Follow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f.ID = m.id("follows", f.ID)
+	m.follows[f.ID] = f
+	return nil
+}
+
+func (m *Memory) DeleteFollow(ID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.follows, ID)
+	return nil
+}
+
+// GetNotes returns the notes of the user for the date in the 2006-01-02 format.
+func (m *Memory) GetNotes(userID int, date string) ([]table.Note, error) {
+	return m.findNotes(func(n table.Note) bool { return n.UserID == userID && n.Date == date }), nil
+}
+
+// GetUpcomingNotes returns the notes of the user starting from the date.
+func (m *Memory) GetUpcomingNotes(userID int, from string) ([]table.Note, error) {
+	return m.findNotes(func(n table.Note) bool { return n.UserID == userID && n.Date >= from }), nil
+}
+
+// findNotes returns the notes matching the condition ordered by the date, the
+// pair and the ID.
+func (m *Memory) findNotes(match func(n table.Note) bool) []table.Note {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var notes []table.Note
+	for _, n := range m.notes {
+		if match(n) {
+			notes = append(notes, n)
+		}
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Pair != b.Pair {
+			return a.Pair < b.Pair
+		}
+		return a.ID < b.ID
+	})
+
+	return notes
+}
+
+func (m *Memory) AddNote(n table.Note) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.notes[n.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	n.ID = m.id("notes", n.ID)
+	m.notes[n.ID] = n
+	return nil
+}
+
+func (m *Memory) DeleteNote(userID int, ID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n, ok := m.notes[ID]; ok && n.UserID == userID {
+		delete(m.notes, ID)
+	}
+
+	return nil
+}
+
+// GetEvents returns the events of the user for the date in the 2006-01-02
+// format.
+func (m *Memory) GetEvents(userID int, date string) ([]table.Event, error) {
+	return m.findEvents(func(e table.Event) bool { return e.UserID == userID && e.Date == date }), nil
+}
+
+// GetEventsByDate returns the events of all users for the date.
+func (m *Memory) GetEventsByDate(date string) ([]table.Event, error) {
+	return m.findEvents(func(e table.Event) bool { return e.Date == date }), nil
+}
+
+// GetUpcomingEvents returns the events of the user starting from the date.
+func (m *Memory) GetUpcomingEvents(userID int, from string) ([]table.Event, error) {
+	return m.findEvents(func(e table.Event) bool { return e.UserID == userID && e.Date >= from }), nil
+}
+
+// findEvents returns the events matching the condition ordered by the date,
+// the start and the ID.
+func (m *Memory) findEvents(match func(e table.Event) bool) []table.Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var events []table.Event
+	for _, e := range m.events {
+		if match(e) {
+			events = append(events, e)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		return a.ID < b.ID
+	})
+
+	return events
+}
+
+func (m *Memory) AddEvent(e table.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.events[e.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	e.ID = m.id("events", e.ID)
+	m.events[e.ID] = e
+	return nil
+}
+
+func (m *Memory) DeleteEvent(userID int, ID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.events[ID]; ok && e.UserID == userID {
+		delete(m.events, ID)
+	}
+
+	return nil
+}
+
+// GetHomework returns the homework of the group due the date in the
+// 2006-01-02 format.
+func (m *Memory) GetHomework(group string, due string) ([]table.Homework, error) {
+	return m.findHomework(func(hw table.Homework) bool {
+		return hw.Group == group && hw.Due == due
+	}), nil
+}
+
+// GetUpcomingHomework returns the homework of the group due starting from the
+// date.
+func (m *Memory) GetUpcomingHomework(group string, from string) ([]table.Homework, error) {
+	return m.findHomework(func(hw table.Homework) bool {
+		return hw.Group == group && hw.Due >= from
+	}), nil
+}
+
+// findHomework returns the homework matching the condition ordered by the due
+// date and the ID.
+func (m *Memory) findHomework(match func(hw table.Homework) bool) []table.Homework {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var homework []table.Homework
+	for _, hw := range m.homework {
+		if match(hw) {
+			homework = append(homework, hw)
+		}
+	}
+
+	sort.Slice(homework, func(i, j int) bool {
+		a, b := homework[i], homework[j]
+		if a.Due != b.Due {
+			return a.Due < b.Due
+		}
+		return a.ID < b.ID
+	})
+
+	return homework
+}
+
+func (m *Memory) AddHomework(hw table.Homework) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.homework[hw.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	hw.ID = m.id("homework", hw.ID)
+	m.homework[hw.ID] = hw
+	return nil
+}
+
+func (m *Memory) DeleteHomework(group string, ID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if hw, ok := m.homework[ID]; ok && hw.Group == group {
+		delete(m.homework, ID)
+	}
+
+	return nil
+}
+
+// GetNotices returns the notices of the group shown on the date in the
+// 2006-01-02 format.
+func (m *Memory) GetNotices(group string, date string) ([]table.Notice, error) {
+	notices := m.findNotices(func(n table.Notice) bool {
+		return n.Group == group && n.FirstDay <= date && n.LastDay >= date
+	})
+
+	sort.Slice(notices, func(i, j int) bool { return notices[i].ID < notices[j].ID })
+	return notices, nil
+}
+
+// GetUpcomingNotices returns the notices of the group shown on the date or
+// later.
+func (m *Memory) GetUpcomingNotices(group string, from string) ([]table.Notice, error) {
+	notices := m.findNotices(func(n table.Notice) bool {
+		return n.Group == group && n.LastDay >= from
+	})
+
+	sort.Slice(notices, func(i, j int) bool {
+		a, b := notices[i], notices[j]
+		if a.FirstDay != b.FirstDay {
+			return a.FirstDay < b.FirstDay
+		}
+		return a.ID < b.ID
+	})
+
+	return notices, nil
+}
+
+func (m *Memory) findNotices(match func(n table.Notice) bool) []table.Notice {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var notices []table.Notice
+	for _, n := range m.notices {
+		if match(n) {
+			notices = append(notices, n)
+		}
+	}
+
+	return notices
+}
+
+func (m *Memory) AddNotice(n table.Notice) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.notices[n.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	n.ID = m.id("notices", n.ID)
+	m.notices[n.ID] = n
+	return nil
+}
+
+func (m *Memory) DeleteNotice(group string, ID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n, ok := m.notices[ID]; ok && n.Group == group {
+		delete(m.notices, ID)
+	}
+
+	return nil
+}
+
+// AddBroadcast creates the broadcast and sets its ID.
+func (m *Memory) AddBroadcast(b *table.Broadcast) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.broadcasts[b.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	b.ID = m.id("broadcasts", b.ID)
+	m.broadcasts[b.ID] = *b
+	return nil
+}
+
+func (m *Memory) SaveBroadcast(b table.Broadcast) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b.ID = m.id("broadcasts", b.ID)
+	m.broadcasts[b.ID] = b
+	return nil
+}
+
+func (m *Memory) GetBroadcastByID(ID int) (table.Broadcast, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, ok := m.broadcasts[ID]
+	if !ok {
+		return table.Broadcast{}, gorm.ErrRecordNotFound
+	}
+
+	return b, nil
+}
+
+// AddBroadcastRecipients creates the recipients and sets their IDs.
+func (m *Memory) AddBroadcastRecipients(rs []table.BroadcastRecipient) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range rs {
+		if _, ok := m.recipients[r.ID]; ok {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	for i := range rs {
+		rs[i].ID = m.id("broadcast_recipients", rs[i].ID)
+		m.recipients[rs[i].ID] = rs[i]
+	}
+
+	return nil
+}
+
+func (m *Memory) GetBroadcastRecipients(broadcastID int) ([]table.BroadcastRecipient, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var rs []table.BroadcastRecipient
+	for _, r := range m.recipients {
+		if r.BroadcastID == broadcastID {
+			rs = append(rs, r)
+		}
+	}
+
+	sort.Slice(rs, func(i, j int) bool { return rs[i].ID < rs[j].ID })
+	return rs, nil
+}
+
+func (m *Memory) SaveBroadcastRecipient(r table.BroadcastRecipient) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r.ID = m.id("broadcast_recipients", r.ID)
+	m.recipients[r.ID] = r
+	return nil
+}
+
+// deliveryKey is the unique key of the delivery.
+type deliveryKey struct {
+	userID int
+	chatID int64
+	kind   string
+	ref    int
+	date   string
+	pair   int
+}
+
+func keyOf(d table.Delivery) deliveryKey {
+	return deliveryKey{d.UserID, d.ChatID, d.Kind, d.Ref, d.Date, d.Pair}
+}
+
+// ClaimDelivery records the delivery, it reports false if it was already
+// recorded, i.e. the notification was sent before.
+func (m *Memory) ClaimDelivery(d *table.Delivery) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := keyOf(*d)
+	for id, other := range m.deliveries {
+		if id == d.ID || keyOf(other) == key {
+			return false, nil
+		}
+	}
+
+	d.ID = m.id("deliveries", d.ID)
+	m.deliveries[d.ID] = *d
+	return true, nil
+}
+
+// GetDeliveries returns the deliveries of the date, of the user if userID is
+// not zero.
+func (m *Memory) GetDeliveries(date string, userID int) ([]table.Delivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ds []table.Delivery
+	for _, d := range m.deliveries {
+		if (date == "" || d.Date == date) && (userID == 0 || d.UserID == userID) {
+			ds = append(ds, d)
+		}
+	}
+
+	sort.Slice(ds, func(i, j int) bool {
+		if !ds[i].SentAt.Equal(ds[j].SentAt) {
+			return ds[i].SentAt.Before(ds[j].SentAt)
+		}
+		return ds[i].ID < ds[j].ID
+	})
+
+	return ds, nil
+}
+
+// DeleteDeliveriesBefore deletes the deliveries of the dates before the date.
+func (m *Memory) DeleteDeliveriesBefore(date string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for id, d := range m.deliveries {
+		if d.Date < date {
+			delete(m.deliveries, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,95 @@
+package storage
+
+import "bot/internal/entity/table"
+
+// UserRepository stores the users.
+type UserRepository interface {
+	GetUserByID(ID int) (table.User, error)
+	GetUserByChatID(chatID int64) (table.User, error)
+	GetUserByNickname(nickname string) (table.User, error)
+	AddUser(us table.User) error
+	SaveUser(us table.User) error
+	DeleteUser(ID int) error
+	Reactivate(userID int) (bool, error)
+	GetSubscribers() ([]table.User, error)
+	GetUsers() ([]table.User, error)
+	GetUsersByGroup(group string) ([]table.User, error)
+}
+
+// ChatRepository stores the group chats.
+type ChatRepository interface {
+	GetChatByID(ID int64) (table.Chat, error)
+	SaveChat(c table.Chat) error
+	DeleteChat(ID int64) error
+	GetSubscribedChats() ([]table.Chat, error)
+}
+
+// FollowRepository stores the groups followed by the users.
+type FollowRepository interface {
+	GetFollows(userID int) ([]table.Follow, error)
+	GetFollowByID(ID int) (table.Follow, error)
+	GetSubscribedFollows() ([]table.Follow, error)
+	AddFollow(f table.Follow) error
+	SaveFollow(f table.Follow) error
+	DeleteFollow(ID int) error
+}
+
+// NoteRepository stores the personal notes and events of the users.
+type NoteRepository interface {
+	GetNotes(userID int, date string) ([]table.Note, error)
+	GetUpcomingNotes(userID int, from string) ([]table.Note, error)
+	AddNote(n table.Note) error
+	DeleteNote(userID int, ID int) error
+
+	GetEvents(userID int, date string) ([]table.Event, error)
+	GetEventsByDate(date string) ([]table.Event, error)
+	GetUpcomingEvents(userID int, from string) ([]table.Event, error)
+	AddEvent(e table.Event) error
+	DeleteEvent(userID int, ID int) error
+}
+
+// GroupRepository stores the homework and the notices of the groups.
+type GroupRepository interface {
+	GetHomework(group string, due string) ([]table.Homework, error)
+	GetUpcomingHomework(group string, from string) ([]table.Homework, error)
+	AddHomework(hw table.Homework) error
+	DeleteHomework(group string, ID int) error
+
+	GetNotices(group string, date string) ([]table.Notice, error)
+	GetUpcomingNotices(group string, from string) ([]table.Notice, error)
+	AddNotice(n table.Notice) error
+	DeleteNotice(group string, ID int) error
+}
+
+// BroadcastRepository stores the broadcasts and their recipients.
+type BroadcastRepository interface {
+	AddBroadcast(b *table.Broadcast) error
+	SaveBroadcast(b table.Broadcast) error
+	GetBroadcastByID(ID int) (table.Broadcast, error)
+	AddBroadcastRecipients(rs []table.BroadcastRecipient) error
+	GetBroadcastRecipients(broadcastID int) ([]table.BroadcastRecipient, error)
+	SaveBroadcastRecipient(r table.BroadcastRecipient) error
+}
+
+// DeliveryRepository stores the ledger of the sent scheduled notifications.
+type DeliveryRepository interface {
+	ClaimDelivery(d *table.Delivery) (bool, error)
+	GetDeliveries(date string, userID int) ([]table.Delivery, error)
+	DeleteDeliveriesBefore(date string) (int64, error)
+}
+
+// Repository is the whole storage of the bot.
+type Repository interface {
+	UserRepository
+	ChatRepository
+	FollowRepository
+	NoteRepository
+	GroupRepository
+	BroadcastRepository
+	DeliveryRepository
+}
+
+var (
+	_ Repository = (*Storage)(nil)
+	_ Repository = (*Memory)(nil)
+)
@@ -5,22 +5,59 @@ import (
 	"bot/internal/entity/table"
 	"errors"
 	"fmt"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// Storage for items.
+// Storage is the Repository kept in an SQL database through GORM.
 type Storage struct {
 	db *gorm.DB
 }
 
+// Group of supported database drivers.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
 type Config struct {
+	// Driver is the database driver, sqlite if empty.
+	Driver string `json:"driver"`
+	// DSN is the file of the sqlite database or the connection string of the
+	// postgres one, e.g. "host=db user=bot password=secret dbname=bot".
 	DSN string `json:"dsn"`
 }
 
+// models are the tables of the storage.
+var models = []interface{}{
+	&table.User{}, &table.Chat{}, &table.Follow{},
+	&table.Note{}, &table.Event{}, &table.Homework{}, &table.Notice{},
+	&table.Broadcast{}, &table.BroadcastRecipient{},
+	&table.Delivery{},
+}
+
+// dialector returns the gorm dialector of the configured driver.
+func (c Config) dialector() (gorm.Dialector, error) {
+	switch c.Driver {
+	case "", DriverSQLite:
+		return sqlite.Open(c.DSN), nil
+	case DriverPostgres:
+		return postgres.Open(c.DSN), nil
+	}
+
+	return nil, fmt.Errorf("unknown driver %q", c.Driver)
+}
+
 // New returns new storage
 func New(config Config) (*Storage, error) {
-	db, err := gorm.Open(sqlite.Open(config.DSN), &gorm.Config{
+	dialector, err := config.dialector()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
 		// TODO: Logger:         logger.GetGormLogger(),
 		TranslateError: true,
 	})
@@ -29,8 +66,7 @@ func New(config Config) (*Storage, error) {
 		return nil, fmt.Errorf("open db: %w", err)
 	}
 
-	err = db.AutoMigrate(&table.User{})
-	if err != nil {
+	if err := db.AutoMigrate(models...); err != nil {
 		return nil, fmt.Errorf("auto migrate: %w", err)
 	}
 
@@ -66,7 +102,7 @@ func (s *Storage) SaveUser(us table.User) error {
 }
 
 func (s *Storage) GetSubscribers() (subs []table.User, err error) {
-	if err := s.db.Find(&subs, "subscribed = ?", true).Error; err != nil {
+	if err := s.db.Order("id").Find(&subs, "subscribed = ? AND blocked = ?", true, false).Error; err != nil {
 		return subs, err
 	}
 
@@ -76,3 +112,390 @@ func (s *Storage) GetSubscribers() (subs []table.User, err error) {
 
 	return subs, nil
 }
+
+// GetUsers returns all users.
+func (s *Storage) GetUsers() (users []table.User, err error) {
+	if err := s.db.Order("id").Find(&users).Error; err != nil {
+		return users, err
+	}
+
+	return users, nil
+}
+
+// GetUsersByGroup returns the users of the group who didn't block the bot.
+func (s *Storage) GetUsersByGroup(group string) (users []table.User, err error) {
+	if err := s.db.Order("sub_group, name").Where("\"group\" = ? AND blocked = ?", group, false).Find(&users).Error; err != nil {
+		return users, err
+	}
+
+	return users, nil
+}
+
+// DeleteUser deletes the user along with the user's follows, notes and events.
+func (s *Storage) DeleteUser(ID int) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&table.Follow{}, &table.Note{}, &table.Event{}} {
+			if err := tx.Delete(model, "user_id = ?", ID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&table.User{}, "id = ?", ID).Error
+	})
+}
+
+func (s *Storage) GetUserByChatID(chatID int64) (u table.User, err error) {
+	if err := s.db.First(&u, "chat_id = ?", chatID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return u, constant.ErrUserNotFound
+		}
+		return u, err
+	}
+
+	return u, nil
+}
+
+// Reactivate resets the blocked flag of the user, it reports whether the user
+// was blocked.
+func (s *Storage) Reactivate(userID int) (bool, error) {
+	res := s.db.Model(&table.User{}).Where("id = ? AND blocked = ?", userID, true).Update("blocked", false)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// GetUserByNickname returns the user by the telegram username without @.
+func (s *Storage) GetUserByNickname(nickname string) (u table.User, err error) {
+	if err := s.db.First(&u, "nickname = ?", nickname).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return u, constant.ErrUserNotFound
+		}
+		return u, err
+	}
+
+	return u, nil
+}
+
+func (s *Storage) GetChatByID(ID int64) (c table.Chat, err error) {
+	if err := s.db.First(&c, "id = ?", ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c, constant.ErrChatNotFound
+		}
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (s *Storage) SaveChat(c table.Chat) error {
+	if err := s.db.Save(&c).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteChat(ID int64) error {
+	if err := s.db.Delete(&table.Chat{}, "id = ?", ID).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) GetSubscribedChats() (chats []table.Chat, err error) {
+	if err := s.db.Order("id").Find(&chats, "subscribed = ?", true).Error; err != nil {
+		return chats, err
+	}
+
+	return chats, nil
+}
+
+func (s *Storage) GetFollows(userID int) (follows []table.Follow, err error) {
+	if err := s.db.Order("id").Find(&follows, "user_id = ?", userID).Error; err != nil {
+		return follows, err
+	}
+
+	return follows, nil
+}
+
+func (s *Storage) GetFollowByID(ID int) (f table.Follow, err error) {
+	if err := s.db.First(&f, "id = ?", ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return f, constant.ErrFollowNotFound
+		}
+		return f, err
+	}
+
+	return f, nil
+}
+
+// GetSubscribedFollows returns the follows with the daily schedule or the pair
+// reminders turned on.
+func (s *Storage) GetSubscribedFollows() (follows []table.Follow, err error) {
+	if err := s.db.Order("id").Find(&follows, "daily = ? OR pair = ?", true, true).Error; err != nil {
+		return follows, err
+	}
+
+	return follows, nil
+}
+
+func (s *Storage) AddFollow(f table.Follow) error {
+	if err := s.db.Create(&f).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveFollow(f table.Follow) error {
+	if err := s.db.Save(&f).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteFollow(ID int) error {
+	if err := s.db.Delete(&table.Follow{}, "id = ?", ID).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetNotes returns the notes of the user for the date in the 2006-01-02 format.
+func (s *Storage) GetNotes(userID int, date string) (notes []table.Note, err error) {
+	if err := s.db.Order("pair, id").Find(&notes, "user_id = ? AND date = ?", userID, date).Error; err != nil {
+		return notes, err
+	}
+
+	return notes, nil
+}
+
+// GetUpcomingNotes returns the notes of the user starting from the date.
+func (s *Storage) GetUpcomingNotes(userID int, from string) (notes []table.Note, err error) {
+	if err := s.db.Order("date, pair, id").Find(&notes, "user_id = ? AND date >= ?", userID, from).Error; err != nil {
+		return notes, err
+	}
+
+	return notes, nil
+}
+
+func (s *Storage) AddNote(n table.Note) error {
+	if err := s.db.Create(&n).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteNote(userID int, ID int) error {
+	if err := s.db.Delete(&table.Note{}, "id = ? AND user_id = ?", ID, userID).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetEvents returns the events of the user for the date in the 2006-01-02
+// format.
+func (s *Storage) GetEvents(userID int, date string) (events []table.Event, err error) {
+	if err := s.db.Order("start, id").Find(&events, "user_id = ? AND date = ?", userID, date).Error; err != nil {
+		return events, err
+	}
+
+	return events, nil
+}
+
+// GetEventsByDate returns the events of all users for the date.
+func (s *Storage) GetEventsByDate(date string) (events []table.Event, err error) {
+	if err := s.db.Order("start, id").Find(&events, "date = ?", date).Error; err != nil {
+		return events, err
+	}
+
+	return events, nil
+}
+
+// GetUpcomingEvents returns the events of the user starting from the date.
+func (s *Storage) GetUpcomingEvents(userID int, from string) (events []table.Event, err error) {
+	if err := s.db.Order("date, start, id").Find(&events, "user_id = ? AND date >= ?", userID, from).Error; err != nil {
+		return events, err
+	}
+
+	return events, nil
+}
+
+func (s *Storage) AddEvent(e table.Event) error {
+	if err := s.db.Create(&e).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteEvent(userID int, ID int) error {
+	if err := s.db.Delete(&table.Event{}, "id = ? AND user_id = ?", ID, userID).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetHomework returns the homework of the group due the date in the
+// 2006-01-02 format.
+func (s *Storage) GetHomework(group string, due string) (homework []table.Homework, err error) {
+	if err := s.db.Order("id").Where("\"group\" = ? AND due = ?", group, due).Find(&homework).Error; err != nil {
+		return homework, err
+	}
+
+	return homework, nil
+}
+
+// GetUpcomingHomework returns the homework of the group due starting from the
+// date.
+func (s *Storage) GetUpcomingHomework(group string, from string) (homework []table.Homework, err error) {
+	if err := s.db.Order("due, id").Where("\"group\" = ? AND due >= ?", group, from).Find(&homework).Error; err != nil {
+		return homework, err
+	}
+
+	return homework, nil
+}
+
+func (s *Storage) AddHomework(hw table.Homework) error {
+	if err := s.db.Create(&hw).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteHomework(group string, ID int) error {
+	if err := s.db.Delete(&table.Homework{}, "id = ? AND \"group\" = ?", ID, group).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetNotices returns the notices of the group shown on the date in the
+// 2006-01-02 format.
+func (s *Storage) GetNotices(group string, date string) (notices []table.Notice, err error) {
+	if err := s.db.Order("id").Where("\"group\" = ? AND first_day <= ? AND last_day >= ?", group, date, date).Find(&notices).Error; err != nil {
+		return notices, err
+	}
+
+	return notices, nil
+}
+
+// GetUpcomingNotices returns the notices of the group shown on the date or
+// later.
+func (s *Storage) GetUpcomingNotices(group string, from string) (notices []table.Notice, err error) {
+	if err := s.db.Order("first_day, id").Where("\"group\" = ? AND last_day >= ?", group, from).Find(&notices).Error; err != nil {
+		return notices, err
+	}
+
+	return notices, nil
+}
+
+func (s *Storage) AddNotice(n table.Notice) error {
+	if err := s.db.Create(&n).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteNotice(group string, ID int) error {
+	if err := s.db.Delete(&table.Notice{}, "id = ? AND \"group\" = ?", ID, group).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddBroadcast creates the broadcast and sets its ID.
+func (s *Storage) AddBroadcast(b *table.Broadcast) error {
+	if err := s.db.Create(b).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveBroadcast(b table.Broadcast) error {
+	if err := s.db.Save(&b).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) GetBroadcastByID(ID int) (b table.Broadcast, err error) {
+	if err := s.db.First(&b, "id = ?", ID).Error; err != nil {
+		return b, err
+	}
+
+	return b, nil
+}
+
+// AddBroadcastRecipients creates the recipients and sets their IDs.
+func (s *Storage) AddBroadcastRecipients(rs []table.BroadcastRecipient) error {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	if err := s.db.CreateInBatches(&rs, 100).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) GetBroadcastRecipients(broadcastID int) (rs []table.BroadcastRecipient, err error) {
+	if err := s.db.Order("id").Find(&rs, "broadcast_id = ?", broadcastID).Error; err != nil {
+		return rs, err
+	}
+
+	return rs, nil
+}
+
+func (s *Storage) SaveBroadcastRecipient(r table.BroadcastRecipient) error {
+	if err := s.db.Save(&r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ClaimDelivery records the delivery, it reports false if it was already
+// recorded, i.e. the notification was sent before.
+func (s *Storage) ClaimDelivery(d *table.Delivery) (bool, error) {
+	res := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(d)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// GetDeliveries returns the deliveries of the date, of the user if userID is
+// not zero.
+func (s *Storage) GetDeliveries(date string, userID int) ([]table.Delivery, error) {
+	q := s.db.Where(&table.Delivery{Date: date, UserID: userID})
+
+	var ds []table.Delivery
+	if err := q.Order("sent_at").Find(&ds).Error; err != nil {
+		return nil, err
+	}
+
+	return ds, nil
+}
+
+// DeleteDeliveriesBefore deletes the deliveries of the dates before the date.
+func (s *Storage) DeleteDeliveriesBefore(date string) (int64, error) {
+	res := s.db.Where("date < ?", date).Delete(&table.Delivery{})
+	return res.RowsAffected, res.Error
+}
@@ -1,210 +1,581 @@
 package storage
 
 import (
-	"bot/internal/entity"
-	"bot/pkg"
-	"math"
-	"reflect"
+	"bot/internal/constant"
+	"bot/internal/entity/table"
+	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 )
 
-func TestStorage_GetItemByName(t *testing.T) {
-	type fields struct {
-		RWMutex    *sync.RWMutex
-		items      map[string]entity.Item
-		allRates   int
-		countRates int
-	}
-	type args struct {
-		name string
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    entity.Item
-		wantErr bool
-	}{
-		{
-			name: "ok",
-			fields: fields{
-				items: map[string]entity.Item{
-					"test": {
-						Name:  "test",
-						Price: 100,
-					},
-				},
-			},
-			args: args{
-				name: "test",
-			},
-			want: entity.Item{
-				Name:  "test",
-				Price: 100,
-			},
-		},
-		{
-			name: "not found",
-			fields: fields{
-				items: map[string]entity.Item{
-					"test": {
-						Name: "test",
-					},
-				},
-			},
-			args: args{
-				name: "test2",
-			},
-			want:    entity.Item{},
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := &Storage{
-				items:      tt.fields.items,
-				allRates:   tt.fields.allRates,
-				countRates: tt.fields.countRates,
-			}
-			got, err := s.GetItemByName(tt.args.name)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GetItemByName() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("GetItemByName() got = %v, want %v", got, tt.want)
+func TestStorage(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		s, err := New(Config{DSN: filepath.Join(t.TempDir(), "test.db")})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		return s
+	})
+}
+
+// TestPostgres runs against the database of TEST_POSTGRES_DSN, e.g. of a
+// container started with
+//
+//	docker run --rm -e POSTGRES_PASSWORD=test -p 5432:5432 postgres:15
+//	TEST_POSTGRES_DSN="host=localhost user=postgres password=test" go test ./internal/storage
+//
+// The tables of the database are dropped.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	testRepository(t, func(t *testing.T) Repository {
+		s, err := New(Config{Driver: DriverPostgres, DSN: dsn})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		// every test starts from the empty tables
+		if err := s.db.Migrator().DropTable(models...); err != nil {
+			t.Fatalf("DropTable() error = %v", err)
+		}
+		if err := s.db.AutoMigrate(models...); err != nil {
+			t.Fatalf("AutoMigrate() error = %v", err)
+		}
+
+		t.Cleanup(func() {
+			if db, err := s.db.DB(); err == nil {
+				db.Close()
 			}
 		})
-	}
+
+		return s
+	})
 }
 
-func TestStorage_GetAll(t *testing.T) {
-	type fields struct {
-		items      map[string]entity.Item
-		allRates   int
-		countRates int
+func TestNew_UnknownDriver(t *testing.T) {
+	if _, err := New(Config{Driver: "mysql", DSN: "bot"}); err == nil {
+		t.Errorf("New() error = nil")
 	}
-	tests := []struct {
-		name   string
-		fields fields
-		want   []string
-	}{
-		{
-			name: "ok",
-			fields: fields{
-				items: map[string]entity.Item{
-					"test": {
-						Name:  "test",
-						Price: 100,
-					},
-					"test2": {
-						Name:  "test2",
-						Price: 200,
-					},
-					"test3": {
-						Name:  "test3",
-						Price: 300,
-					},
-				},
-			},
-			want: []string{
-				"test", "test2", "test3",
-			},
-		},
-		{
-			name: "empty",
-			fields: fields{
-				items: map[string]entity.Item{},
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := &Storage{
-				RWMutex:    sync.RWMutex{},
-				items:      tt.fields.items,
-				allRates:   tt.fields.allRates,
-				countRates: tt.fields.countRates,
+}
+
+func TestMemory(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewMemory()
+	})
+}
+
+func TestMemory_ClaimDeliveryConcurrent(t *testing.T) {
+	m := NewMemory()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	claimed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := m.ClaimDelivery(&table.Delivery{UserID: 1, Kind: table.DeliveryDaily, Date: "2023-10-02"})
+			if err != nil {
+				t.Errorf("ClaimDelivery() error = %v", err)
 			}
-			if got := s.GetAll(); !pkg.Like(got, tt.want) {
-				t.Errorf("GetAll() = %v, want %v", got, tt.want)
+			if ok {
+				mu.Lock()
+				claimed++
+				mu.Unlock()
 			}
-		})
+		}()
+	}
+	wg.Wait()
+
+	if claimed != 1 {
+		t.Errorf("claimed %d times, want 1", claimed)
+	}
+}
+
+// testRepository is the contract every Repository implementation must pass,
+// open returns a new empty repository.
+func testRepository(t *testing.T, open func(t *testing.T) Repository) {
+	t.Run("users", func(t *testing.T) { testUsers(t, open(t)) })
+	t.Run("chats", func(t *testing.T) { testChats(t, open(t)) })
+	t.Run("follows", func(t *testing.T) { testFollows(t, open(t)) })
+	t.Run("notes", func(t *testing.T) { testNotes(t, open(t)) })
+	t.Run("group", func(t *testing.T) { testGroup(t, open(t)) })
+	t.Run("broadcasts", func(t *testing.T) { testBroadcasts(t, open(t)) })
+	t.Run("deliveries", func(t *testing.T) { testDeliveries(t, open(t)) })
+}
+
+func testUsers(t *testing.T, r Repository) {
+	if _, err := r.GetUserByID(1); !errors.Is(err, constant.ErrUserNotFound) {
+		t.Fatalf("GetUserByID() error = %v, want %v", err, constant.ErrUserNotFound)
+	}
+	if _, err := r.GetSubscribers(); !errors.Is(err, constant.ErrNoSubscribers) {
+		t.Fatalf("GetSubscribers() error = %v, want %v", err, constant.ErrNoSubscribers)
+	}
+
+	users := []table.User{
+		{ID: 3, Name: "Вера", ChatID: 30, Nickname: "vera", Group: "ИС-21", SubGroup: 2, Subscribed: true},
+		{ID: 1, Name: "Борис", ChatID: 10, Group: "ИС-21", SubGroup: 1},
+		{ID: 2, Name: "Анна", ChatID: 20, Group: "ИС-21", SubGroup: 1, Subscribed: true, Blocked: true},
+		{ID: 4, Name: "Глеб", ChatID: 40, Group: "ТО-11", Subscribed: true, DailyHour: 7},
+	}
+	for _, u := range users {
+		if err := r.AddUser(u); err != nil {
+			t.Fatalf("AddUser() error = %v", err)
+		}
+	}
+	if err := r.AddUser(users[0]); err == nil {
+		t.Errorf("AddUser() of an existing user error = nil")
+	}
+
+	u, err := r.GetUserByID(1)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if u.Name != "Борис" || u.DailyHour != 8 || u.RemindBefore != 10 {
+		t.Errorf("GetUserByID() = %+v, want the defaults set", u)
+	}
+	if u, _ := r.GetUserByID(4); u.DailyHour != 7 {
+		t.Errorf("DailyHour = %d, want 7", u.DailyHour)
+	}
+
+	if u, err := r.GetUserByChatID(30); err != nil || u.ID != 3 {
+		t.Errorf("GetUserByChatID() = %d, %v, want 3", u.ID, err)
+	}
+	if u, err := r.GetUserByNickname("vera"); err != nil || u.ID != 3 {
+		t.Errorf("GetUserByNickname() = %d, %v, want 3", u.ID, err)
+	}
+	if _, err := r.GetUserByNickname("nobody"); !errors.Is(err, constant.ErrUserNotFound) {
+		t.Errorf("GetUserByNickname() error = %v, want %v", err, constant.ErrUserNotFound)
+	}
+
+	assertIDs(t, "GetSubscribers", userIDs(r.GetSubscribers()), 3, 4)
+	assertIDs(t, "GetUsers", userIDs(r.GetUsers()), 1, 2, 3, 4)
+	assertIDs(t, "GetUsersByGroup", userIDs(r.GetUsersByGroup("ИС-21")), 1, 3)
+	assertIDs(t, "GetUsersByGroup", userIDs(r.GetUsersByGroup("")))
+
+	u.Subscribed = true
+	u.DailyHour = 0
+	if err := r.SaveUser(u); err != nil {
+		t.Fatalf("SaveUser() error = %v", err)
+	}
+	if u, _ := r.GetUserByID(1); !u.Subscribed || u.DailyHour != 0 {
+		t.Errorf("SaveUser() saved %+v", u)
+	}
+	if err := r.SaveUser(table.User{ID: 5, Name: "Дина"}); err != nil {
+		t.Fatalf("SaveUser() of a new user error = %v", err)
+	}
+	if u, err := r.GetUserByID(5); err != nil || u.Name != "Дина" {
+		t.Errorf("GetUserByID() = %+v, %v after SaveUser()", u, err)
+	}
+
+	if ok, err := r.Reactivate(2); err != nil || !ok {
+		t.Errorf("Reactivate() = %v, %v, want true", ok, err)
+	}
+	if ok, err := r.Reactivate(2); err != nil || ok {
+		t.Errorf("Reactivate() again = %v, %v, want false", ok, err)
+	}
+	assertIDs(t, "GetUsersByGroup", userIDs(r.GetUsersByGroup("ИС-21")), 2, 1, 3)
+
+	if err := r.AddFollow(table.Follow{UserID: 3, Group: "ТО-11", Daily: true}); err != nil {
+		t.Fatalf("AddFollow() error = %v", err)
+	}
+	if err := r.AddNote(table.Note{UserID: 3, Date: "2023-10-02", Pair: 1, Text: "a"}); err != nil {
+		t.Fatalf("AddNote() error = %v", err)
+	}
+	if err := r.DeleteUser(3); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if _, err := r.GetUserByID(3); !errors.Is(err, constant.ErrUserNotFound) {
+		t.Errorf("GetUserByID() error = %v after DeleteUser()", err)
+	}
+	if fs, _ := r.GetFollows(3); len(fs) != 0 {
+		t.Errorf("GetFollows() = %v after DeleteUser()", fs)
+	}
+	if ns, _ := r.GetUpcomingNotes(3, ""); len(ns) != 0 {
+		t.Errorf("GetUpcomingNotes() = %v after DeleteUser()", ns)
+	}
+}
+
+func testChats(t *testing.T, r Repository) {
+	if _, err := r.GetChatByID(-1); !errors.Is(err, constant.ErrChatNotFound) {
+		t.Fatalf("GetChatByID() error = %v, want %v", err, constant.ErrChatNotFound)
+	}
+
+	for _, c := range []table.Chat{
+		{ID: -2, Title: "b", Group: "ИС-21", Subscribed: true},
+		{ID: -1, Title: "a", Group: "ИС-21"},
+	} {
+		if err := r.SaveChat(c); err != nil {
+			t.Fatalf("SaveChat() error = %v", err)
+		}
+	}
+
+	c, err := r.GetChatByID(-1)
+	if err != nil || c.Title != "a" {
+		t.Fatalf("GetChatByID() = %+v, %v", c, err)
+	}
+
+	c.Subscribed = true
+	if err := r.SaveChat(c); err != nil {
+		t.Fatalf("SaveChat() error = %v", err)
+	}
+
+	chats, err := r.GetSubscribedChats()
+	if err != nil || len(chats) != 2 {
+		t.Fatalf("GetSubscribedChats() = %v, %v, want 2 chats", chats, err)
+	}
+
+	if err := r.DeleteChat(-2); err != nil {
+		t.Fatalf("DeleteChat() error = %v", err)
+	}
+	if _, err := r.GetChatByID(-2); !errors.Is(err, constant.ErrChatNotFound) {
+		t.Errorf("GetChatByID() error = %v after DeleteChat()", err)
+	}
+}
+
+func testFollows(t *testing.T, r Repository) {
+	if _, err := r.GetFollowByID(1); !errors.Is(err, constant.ErrFollowNotFound) {
+		t.Fatalf("GetFollowByID() error = %v, want %v", err, constant.ErrFollowNotFound)
+	}
+
+	for _, f := range []table.Follow{
+		{UserID: 1, Group: "ТО-11", Daily: true},
+		{UserID: 1, Group: "ИС-22"},
+		{UserID: 2, Group: "ТО-11", Pair: true},
+	} {
+		if err := r.AddFollow(f); err != nil {
+			t.Fatalf("AddFollow() error = %v", err)
+		}
+	}
+
+	follows, err := r.GetFollows(1)
+	if err != nil || len(follows) != 2 || follows[0].Group != "ТО-11" || follows[1].Group != "ИС-22" {
+		t.Fatalf("GetFollows() = %+v, %v", follows, err)
+	}
+
+	f, err := r.GetFollowByID(follows[1].ID)
+	if err != nil || f.Group != "ИС-22" {
+		t.Fatalf("GetFollowByID() = %+v, %v", f, err)
+	}
+
+	subscribed, err := r.GetSubscribedFollows()
+	if err != nil || len(subscribed) != 2 {
+		t.Errorf("GetSubscribedFollows() = %+v, %v, want 2 follows", subscribed, err)
+	}
+
+	f.SubGroup = 2
+	if err := r.SaveFollow(f); err != nil {
+		t.Fatalf("SaveFollow() error = %v", err)
+	}
+	if f, _ := r.GetFollowByID(f.ID); f.SubGroup != 2 {
+		t.Errorf("SaveFollow() saved %+v", f)
+	}
+
+	if err := r.DeleteFollow(f.ID); err != nil {
+		t.Fatalf("DeleteFollow() error = %v", err)
+	}
+	if follows, _ := r.GetFollows(1); len(follows) != 1 {
+		t.Errorf("GetFollows() = %+v after DeleteFollow()", follows)
 	}
 }
 
-func TestStorage_GetAVG(t *testing.T) {
-	s := &Storage{
-		items: map[string]entity.Item{
-			"test": {
-				Name:  "test",
-				Price: 100,
-			},
+func testNotes(t *testing.T, r Repository) {
+	for _, n := range []table.Note{
+		{UserID: 1, Date: "2023-10-03", Pair: 2, Text: "c"},
+		{UserID: 1, Date: "2023-10-02", Pair: 3, Text: "b"},
+		{UserID: 1, Date: "2023-10-02", Pair: 1, Text: "a"},
+		{UserID: 2, Date: "2023-10-02", Pair: 1, Text: "x"},
+		{UserID: 1, Date: "2023-10-01", Pair: 1, Text: "old"},
+	} {
+		if err := r.AddNote(n); err != nil {
+			t.Fatalf("AddNote() error = %v", err)
+		}
+	}
 
-			"test2": {
-				Name:  "test2",
-				Price: 200,
-			},
-		},
-		allRates:   0,
-		countRates: 0,
+	notes, err := r.GetNotes(1, "2023-10-02")
+	if err != nil || noteTexts(notes) != "ab" {
+		t.Errorf("GetNotes() = %q, %v, want ab", noteTexts(notes), err)
 	}
 
-	avg := s.GetAVG()
-	if !math.IsNaN(avg) {
-		t.Errorf("GetAVG() = %v, want NaN", avg)
+	notes, err = r.GetUpcomingNotes(1, "2023-10-02")
+	if err != nil || noteTexts(notes) != "abc" {
+		t.Errorf("GetUpcomingNotes() = %q, %v, want abc", noteTexts(notes), err)
 	}
 
-	s.countRates = 2
-	s.allRates = 100
+	// only the author deletes the note
+	if err := r.DeleteNote(2, notes[0].ID); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+	if err := r.DeleteNote(1, notes[1].ID); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+	if notes, _ := r.GetUpcomingNotes(1, "2023-10-02"); noteTexts(notes) != "ac" {
+		t.Errorf("GetUpcomingNotes() = %q after DeleteNote(), want ac", noteTexts(notes))
+	}
 
-	avg = s.GetAVG()
-	if avg != 50 {
-		t.Errorf("GetAVG() = %v, want 50", avg)
+	for _, e := range []table.Event{
+		{UserID: 1, Date: "2023-10-02", Start: 16 * 60, Title: "b"},
+		{UserID: 2, Date: "2023-10-02", Start: 9 * 60, Title: "x"},
+		{UserID: 1, Date: "2023-10-02", Start: 10 * 60, Title: "a"},
+		{UserID: 1, Date: "2023-10-04", Start: 8 * 60, Title: "c"},
+	} {
+		if err := r.AddEvent(e); err != nil {
+			t.Fatalf("AddEvent() error = %v", err)
+		}
 	}
 
-	s.countRates = 3
-	s.allRates = 47
+	events, err := r.GetEvents(1, "2023-10-02")
+	if err != nil || eventTitles(events) != "ab" {
+		t.Errorf("GetEvents() = %q, %v, want ab", eventTitles(events), err)
+	}
+	events, err = r.GetEventsByDate("2023-10-02")
+	if err != nil || eventTitles(events) != "xab" {
+		t.Errorf("GetEventsByDate() = %q, %v, want xab", eventTitles(events), err)
+	}
+	events, err = r.GetUpcomingEvents(1, "2023-10-03")
+	if err != nil || eventTitles(events) != "c" {
+		t.Fatalf("GetUpcomingEvents() = %q, %v, want c", eventTitles(events), err)
+	}
 
-	avg = s.GetAVG()
-	if avg != 15.666666666666666 {
-		t.Errorf("GetAVG() = %v, want 15.666666666666666", avg)
+	if err := r.DeleteEvent(1, events[0].ID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	if events, _ := r.GetUpcomingEvents(1, ""); eventTitles(events) != "ab" {
+		t.Errorf("GetUpcomingEvents() = %q after DeleteEvent(), want ab", eventTitles(events))
 	}
 }
 
-func TestStorage_AddRate(t *testing.T) {
-	s := &Storage{
-		items: map[string]entity.Item{
-			"test": {
-				Name:  "test",
-				Price: 100,
-			},
+func testGroup(t *testing.T, r Repository) {
+	for _, hw := range []table.Homework{
+		{Group: "ИС-21", Due: "2023-10-03", Text: "b"},
+		{Group: "ИС-21", Due: "2023-10-02", Text: "a"},
+		{Group: "ТО-11", Due: "2023-10-02", Text: "x"},
+		{Group: "ИС-21", Due: "2023-10-01", Text: "old"},
+	} {
+		if err := r.AddHomework(hw); err != nil {
+			t.Fatalf("AddHomework() error = %v", err)
+		}
+	}
+
+	homework, err := r.GetHomework("ИС-21", "2023-10-02")
+	if err != nil || len(homework) != 1 || homework[0].Text != "a" {
+		t.Errorf("GetHomework() = %+v, %v", homework, err)
+	}
+
+	// the empty group or date is a value, not a missing filter
+	if homework, _ := r.GetHomework("", "2023-10-02"); len(homework) != 0 {
+		t.Errorf("GetHomework() of no group = %+v", homework)
+	}
+	if homework, _ := r.GetHomework("ИС-21", ""); len(homework) != 0 {
+		t.Errorf("GetHomework() of no date = %+v", homework)
+	}
+	if homework, _ := r.GetUpcomingHomework("", "2023-10-02"); len(homework) != 0 {
+		t.Errorf("GetUpcomingHomework() of no group = %+v", homework)
+	}
+
+	homework, err = r.GetUpcomingHomework("ИС-21", "2023-10-02")
+	if err != nil || len(homework) != 2 || homework[0].Text != "a" || homework[1].Text != "b" {
+		t.Fatalf("GetUpcomingHomework() = %+v, %v", homework, err)
+	}
+
+	// the homework of another group, of no group or with no ID isn't deleted
+	if err := r.DeleteHomework("ТО-11", homework[0].ID); err != nil {
+		t.Fatalf("DeleteHomework() error = %v", err)
+	}
+	if err := r.DeleteHomework("", homework[0].ID); err != nil {
+		t.Fatalf("DeleteHomework() error = %v", err)
+	}
+	if err := r.DeleteHomework("ИС-21", 0); err != nil {
+		t.Fatalf("DeleteHomework() error = %v", err)
+	}
+	if err := r.DeleteHomework("ИС-21", homework[1].ID); err != nil {
+		t.Fatalf("DeleteHomework() error = %v", err)
+	}
+	if homework, _ := r.GetUpcomingHomework("ИС-21", "2023-10-02"); len(homework) != 1 || homework[0].Text != "a" {
+		t.Errorf("GetUpcomingHomework() = %+v after DeleteHomework()", homework)
+	}
+
+	for _, n := range []table.Notice{
+		{Group: "ИС-21", FirstDay: "2023-10-04", LastDay: "2023-10-05", Text: "b"},
+		{Group: "ИС-21", FirstDay: "2023-10-01", LastDay: "2023-10-03", Text: "a"},
+		{Group: "ТО-11", FirstDay: "2023-10-01", LastDay: "2023-10-09", Text: "x"},
+		{Group: "ИС-21", FirstDay: "2023-09-01", LastDay: "2023-09-02", Text: "old"},
+	} {
+		if err := r.AddNotice(n); err != nil {
+			t.Fatalf("AddNotice() error = %v", err)
+		}
+	}
 
-			"test2": {
-				Name:  "test2",
-				Price: 200,
-			},
-		},
-		allRates:   0,
-		countRates: 0,
+	notices, err := r.GetNotices("ИС-21", "2023-10-03")
+	if err != nil || len(notices) != 1 || notices[0].Text != "a" {
+		t.Errorf("GetNotices() = %+v, %v", notices, err)
 	}
 
-	s.AddRate(100)
+	if notices, _ := r.GetNotices("", "2023-10-03"); len(notices) != 0 {
+		t.Errorf("GetNotices() of no group = %+v", notices)
+	}
+	if notices, _ := r.GetUpcomingNotices("", "2023-10-02"); len(notices) != 0 {
+		t.Errorf("GetUpcomingNotices() of no group = %+v", notices)
+	}
 
-	if s.allRates != 100 && s.countRates != 1 {
-		t.Errorf("AddRate() = %v, %v, want 100, 1", s.allRates, s.countRates)
+	notices, err = r.GetUpcomingNotices("ИС-21", "2023-10-02")
+	if err != nil || len(notices) != 2 || notices[0].Text != "a" || notices[1].Text != "b" {
+		t.Fatalf("GetUpcomingNotices() = %+v, %v", notices, err)
 	}
 
-	s.AddRate(200)
+	for _, del := range []struct {
+		group string
+		ID    int
+	}{
+		{"ТО-11", notices[0].ID},
+		{"", notices[0].ID},
+		{"ИС-21", 0},
+		{"ИС-21", notices[0].ID},
+	} {
+		if err := r.DeleteNotice(del.group, del.ID); err != nil {
+			t.Fatalf("DeleteNotice() error = %v", err)
+		}
+	}
+	if notices, _ := r.GetUpcomingNotices("ИС-21", "2023-10-02"); len(notices) != 1 || notices[0].Text != "b" {
+		t.Errorf("GetUpcomingNotices() = %+v after DeleteNotice()", notices)
+	}
+}
 
-	if s.allRates != 300 && s.countRates != 2 {
-		t.Errorf("AddRate() = %v, %v, want 300, 2", s.allRates, s.countRates)
+func testBroadcasts(t *testing.T, r Repository) {
+	b := table.Broadcast{AuthorID: 1, Text: "hi", Total: 2, CreatedAt: time.Now()}
+	if err := r.AddBroadcast(&b); err != nil {
+		t.Fatalf("AddBroadcast() error = %v", err)
+	}
+	if b.ID == 0 {
+		t.Fatalf("AddBroadcast() didn't set the ID")
 	}
 
-	s.AddRate(300)
+	rs := []table.BroadcastRecipient{
+		{BroadcastID: b.ID, UserID: 1, ChatID: 10, Status: table.RecipientPending},
+		{BroadcastID: b.ID, UserID: 2, ChatID: 20, Status: table.RecipientPending},
+		{BroadcastID: b.ID + 1, UserID: 3, ChatID: 30, Status: table.RecipientPending},
+	}
+	if err := r.AddBroadcastRecipients(rs); err != nil {
+		t.Fatalf("AddBroadcastRecipients() error = %v", err)
+	}
+	if rs[0].ID == 0 || rs[1].ID == 0 || rs[0].ID == rs[1].ID {
+		t.Fatalf("AddBroadcastRecipients() set the IDs %d, %d", rs[0].ID, rs[1].ID)
+	}
+	if err := r.AddBroadcastRecipients(nil); err != nil {
+		t.Fatalf("AddBroadcastRecipients() of none error = %v", err)
+	}
+
+	rs[1].Status = table.RecipientSent
+	if err := r.SaveBroadcastRecipient(rs[1]); err != nil {
+		t.Fatalf("SaveBroadcastRecipient() error = %v", err)
+	}
+
+	got, err := r.GetBroadcastRecipients(b.ID)
+	if err != nil || len(got) != 2 || got[0].ChatID != 10 || got[1].Status != table.RecipientSent {
+		t.Errorf("GetBroadcastRecipients() = %+v, %v", got, err)
+	}
+
+	b.Sent = 2
+	b.FinishedAt = time.Now()
+	if err := r.SaveBroadcast(b); err != nil {
+		t.Fatalf("SaveBroadcast() error = %v", err)
+	}
+
+	saved, err := r.GetBroadcastByID(b.ID)
+	if err != nil || saved.Sent != 2 || !saved.FinishedAt.Equal(b.FinishedAt) {
+		t.Errorf("GetBroadcastByID() = %+v, %v", saved, err)
+	}
+	if _, err := r.GetBroadcastByID(b.ID + 1); err == nil {
+		t.Errorf("GetBroadcastByID() of a missing broadcast error = nil")
+	}
+}
+
+func testDeliveries(t *testing.T, r Repository) {
+	at := time.Date(2023, 10, 2, 8, 0, 0, 0, time.UTC)
+
+	for i, d := range []table.Delivery{
+		{UserID: 1, Kind: table.DeliveryPair, Date: "2023-10-02", Pair: 2, SentAt: at.Add(time.Hour)},
+		{UserID: 1, Kind: table.DeliveryDaily, Date: "2023-10-02", SentAt: at},
+		{UserID: 2, Kind: table.DeliveryDaily, Date: "2023-10-02", SentAt: at.Add(time.Minute)},
+		{UserID: 1, Kind: table.DeliveryDaily, Date: "2023-10-01", SentAt: at.AddDate(0, 0, -1)},
+		{ChatID: -1, Kind: table.DeliveryChat, Date: "2023-09-30", SentAt: at.AddDate(0, 0, -2)},
+	} {
+		ok, err := r.ClaimDelivery(&d)
+		if err != nil || !ok {
+			t.Fatalf("ClaimDelivery(%d) = %v, %v, want true", i, ok, err)
+		}
+		if d.ID == 0 {
+			t.Fatalf("ClaimDelivery(%d) didn't set the ID", i)
+		}
+	}
+
+	again := table.Delivery{UserID: 1, Kind: table.DeliveryDaily, Date: "2023-10-02", SentAt: at.Add(time.Hour)}
+	if ok, err := r.ClaimDelivery(&again); err != nil || ok {
+		t.Errorf("ClaimDelivery() of a sent delivery = %v, %v, want false", ok, err)
+	}
+
+	ds, err := r.GetDeliveries("2023-10-02", 0)
+	if err != nil || len(ds) != 3 || ds[0].Kind != table.DeliveryDaily || ds[0].UserID != 1 || ds[2].Kind != table.DeliveryPair {
+		t.Errorf("GetDeliveries() = %+v, %v", ds, err)
+	}
+	if ds, _ := r.GetDeliveries("2023-10-02", 2); len(ds) != 1 || ds[0].UserID != 2 {
+		t.Errorf("GetDeliveries() of the user = %+v", ds)
+	}
+
+	n, err := r.DeleteDeliveriesBefore("2023-10-02")
+	if err != nil || n != 2 {
+		t.Errorf("DeleteDeliveriesBefore() = %d, %v, want 2", n, err)
+	}
+	if ds, _ := r.GetDeliveries("2023-10-01", 0); len(ds) != 0 {
+		t.Errorf("GetDeliveries() = %+v after DeleteDeliveriesBefore()", ds)
+	}
+}
+
+func userIDs(users []table.User, err error) []int {
+	if err != nil {
+		return nil
+	}
+
+	ids := make([]int, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+
+	return ids
+}
+
+func assertIDs(t *testing.T, name string, got []int, want ...int) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Errorf("%s() = %v, want %v", name, got, want)
+		return
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s() = %v, want %v", name, got, want)
+			return
+		}
+	}
+}
+
+func noteTexts(notes []table.Note) (s string) {
+	for _, n := range notes {
+		s += n.Text
+	}
+	return s
+}
 
-	if s.allRates != 600 && s.countRates != 3 {
-		t.Errorf("AddRate() = %v, %v, want 600, 3", s.allRates, s.countRates)
+func eventTitles(events []table.Event) (s string) {
+	for _, e := range events {
+		s += e.Title
 	}
+	return s
 }